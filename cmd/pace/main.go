@@ -8,7 +8,7 @@ import (
 )
 
 func main() {
-	err := command.RootCommand.Run(os.Args[1:])
+	err := command.Execute(os.Args[1:])
 	if err != nil {
 		logger.Error("%s", err.Error())
 		os.Exit(1)
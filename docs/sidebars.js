@@ -11,7 +11,7 @@ const sidebars = {
     {
       type: 'category',
       label: 'Commands',
-      items: ['commands/run', 'commands/watch', 'commands/list', 'commands/update', 'commands/version'],
+      items: ['commands/run', 'commands/watch', 'commands/up', 'commands/show', 'commands/list', 'commands/graph', 'commands/check', 'commands/convert', 'commands/config', 'commands/schema', 'commands/deps', 'commands/update', 'commands/version'],
     },
     'examples',
   ],
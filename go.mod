@@ -5,9 +5,11 @@ go 1.24.0
 toolchain go1.24.10
 
 require (
+	github.com/BurntSushi/toml v1.6.0
 	github.com/azuyamat/gear v0.0.0-20251126024211-3a86d43d81ef
 	github.com/azuyamat/globber v0.0.0-20251126020500-7fa19a3402a2
 	github.com/fsnotify/fsnotify v1.9.0
+	golang.org/x/sys v0.38.0
+	golang.org/x/term v0.37.0
+	gopkg.in/yaml.v3 v3.0.1
 )
-
-require golang.org/x/sys v0.38.0 // indirect
@@ -0,0 +1,99 @@
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	gear "github.com/azuyamat/gear/command"
+	"github.com/azuyamat/pace/internal/config"
+	"github.com/azuyamat/pace/internal/logger"
+	"github.com/azuyamat/pace/internal/runner"
+)
+
+var checkCommand = gear.NewExecutableCommand("check", "Look for likely mistakes in the config, such as unused hooks or inputs matching no files").
+	Flags(
+		gear.NewStringFlag("ignore", "", "Comma-separated rule IDs to skip", ""),
+		gear.NewBoolFlag("json", "", "Print the findings as JSON", false),
+		gear.NewBoolFlag("rules", "", "List the rules and exit", false)).
+	Handler(checkHandler)
+
+func init() {
+	RootCommand.AddChild(checkCommand)
+}
+
+func checkHandler(ctx *gear.Context, args gear.ValidatedArgs) error {
+	if args.FlagBool("rules") {
+		for _, rule := range runner.LintRules {
+			logger.Printf("%-22s %-8s %s\n", rule.ID, rule.Severity, rule.Description)
+		}
+		return nil
+	}
+
+	ignore := make([]string, 0)
+	for _, id := range strings.Split(args.FlagString("ignore"), ",") {
+		if id = strings.TrimSpace(id); id == "" {
+			continue
+		}
+		if !isLintRule(id) {
+			return fmt.Errorf("unknown rule '%s', run 'pace check --rules' to list them", id)
+		}
+		ignore = append(ignore, id)
+	}
+
+	jsonOutput := args.FlagBool("json")
+	if jsonOutput {
+		// Keep warnings printed while loading out of the JSON document.
+		logger.Default.SetEnabled(false)
+		defer logger.Default.SetEnabled(true)
+	}
+
+	cfg, err := config.GetConfig()
+	if err != nil {
+		return err
+	}
+	findings := runner.Lint(cfg, ignore)
+
+	counts := make(map[runner.Severity]int)
+	for _, finding := range findings {
+		counts[finding.Severity]++
+	}
+
+	if jsonOutput {
+		data, err := json.MarshalIndent(findings, "", "  ")
+		if err != nil {
+			return err
+		}
+		logger.Println(string(data))
+	} else {
+		for _, finding := range findings {
+			logger.Printf("%s: %s: %s [%s]\n", finding.Source, finding.Severity, finding.Message, finding.Rule)
+		}
+		if len(findings) == 0 {
+			logger.Success("No problems found")
+		} else {
+			logger.Printf("\n%s: %s, %s, %d info\n", plural(len(findings), "problem"), plural(counts[runner.SeverityError], "error"), plural(counts[runner.SeverityWarning], "warning"), counts[runner.SeverityInfo])
+		}
+	}
+
+	if counts[runner.SeverityError] > 0 {
+		return fmt.Errorf("check found %s", plural(counts[runner.SeverityError], "error"))
+	}
+	return nil
+}
+
+func isLintRule(id string) bool {
+	for _, rule := range runner.LintRules {
+		if rule.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+func plural(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("1 %s", noun)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
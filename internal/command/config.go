@@ -0,0 +1,49 @@
+package command
+
+import (
+	"encoding/json"
+
+	gear "github.com/azuyamat/gear/command"
+	"github.com/azuyamat/pace/internal/config"
+	"github.com/azuyamat/pace/internal/logger"
+)
+
+var configCommand = gear.NewSubcommand("config", "Inspect the loaded configuration").
+	AddChild(configDumpCommand)
+
+var configDumpCommand = gear.NewExecutableCommand("dump", "Print the config after imports, templates and variables are resolved").
+	Flags(
+		gear.NewBoolFlag("json", "", "Print the config as JSON", false)).
+	Handler(configDumpHandler)
+
+func init() {
+	RootCommand.AddChild(configCommand)
+}
+
+func configDumpHandler(ctx *gear.Context, args gear.ValidatedArgs) error {
+	jsonOutput := args.FlagBool("json")
+	if jsonOutput {
+		// Keep warnings printed while loading out of the JSON document.
+		logger.Default.SetEnabled(false)
+		defer logger.Default.SetEnabled(true)
+	}
+
+	cfg, err := config.GetConfig()
+	if err != nil {
+		return err
+	}
+
+	if jsonOutput {
+		data, err := json.MarshalIndent(cfg, "", "  ")
+		if err != nil {
+			return err
+		}
+		logger.Println(string(data))
+		return nil
+	}
+
+	// The imported definitions are already merged in.
+	cfg.Imports = nil
+	logger.Printf("%s", cfg.String())
+	return nil
+}
@@ -0,0 +1,48 @@
+package command
+
+import (
+	"fmt"
+
+	gear "github.com/azuyamat/gear/command"
+	"github.com/azuyamat/pace/internal/config"
+	"github.com/azuyamat/pace/internal/config/types"
+	"github.com/azuyamat/pace/internal/logger"
+)
+
+var convertCommand = gear.NewExecutableCommand("convert", "Print the config file in another format").
+	Flags(
+		gear.NewStringFlag("to", "t", "Output format: pace, yaml, toml or json", "yaml")).
+	Args(
+		gear.NewStringArg("file", "Config file to convert, the one pace would load if omitted").AsOptional()).
+	Handler(convertHandler)
+
+func init() {
+	RootCommand.AddChild(convertCommand)
+}
+
+func convertHandler(ctx *gear.Context, args gear.ValidatedArgs) error {
+	format, err := types.ParseFormat(args.FlagString("to"))
+	if err != nil {
+		return err
+	}
+
+	path := args.String("file")
+	if path == "" {
+		if path, err = config.FindConfigFile(); err != nil {
+			return err
+		}
+	}
+
+	// Imports, templates and variables are kept as written, so that the
+	// output can replace the original file.
+	cfg, err := config.ParseUnprocessed(path)
+	if err != nil {
+		return fmt.Errorf("failed to parse %s: %v", path, err)
+	}
+	output, err := cfg.Encode(format)
+	if err != nil {
+		return err
+	}
+	logger.Printf("%s", output)
+	return nil
+}
@@ -0,0 +1,59 @@
+package command
+
+import (
+	gear "github.com/azuyamat/gear/command"
+	"github.com/azuyamat/pace/internal/config"
+	"github.com/azuyamat/pace/internal/logger"
+)
+
+var depsCommand = gear.NewSubcommand("deps", "Manage imports fetched from git repositories").
+	AddChild(depsUpdateCommand)
+
+var depsUpdateCommand = gear.NewExecutableCommand("update", "Fetch git imports again and update pace.lock").
+	Handler(depsUpdateHandler)
+
+func init() {
+	RootCommand.AddChild(depsCommand)
+}
+
+func depsUpdateHandler(ctx *gear.Context, args gear.ValidatedArgs) error {
+	before, after, err := config.UpdateImports()
+	if err != nil {
+		return err
+	}
+	if len(after) == 0 && len(before) == 0 {
+		logger.Info("No git imports to update")
+		return nil
+	}
+
+	previous := make(map[string]string, len(before))
+	for _, locked := range before {
+		previous[locked.Import] = locked.Commit
+	}
+
+	for _, locked := range after {
+		commit, exists := previous[locked.Import]
+		delete(previous, locked.Import)
+		switch {
+		case !exists:
+			logger.Success("Locked %s at %s", locked.Import, shortCommit(locked.Commit))
+		case commit != locked.Commit:
+			logger.Success("Updated %s from %s to %s", locked.Import, shortCommit(commit), shortCommit(locked.Commit))
+		default:
+			logger.Info("%s is up to date at %s", locked.Import, shortCommit(locked.Commit))
+		}
+	}
+	for _, locked := range before {
+		if _, removed := previous[locked.Import]; removed {
+			logger.Info("Removed %s, it is no longer imported", locked.Import)
+		}
+	}
+	return nil
+}
+
+func shortCommit(commit string) string {
+	if len(commit) > 12 {
+		return commit[:12]
+	}
+	return commit
+}
@@ -0,0 +1,129 @@
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	gear "github.com/azuyamat/gear/command"
+	"github.com/azuyamat/pace/internal/config"
+	"github.com/azuyamat/pace/internal/logger"
+	"github.com/azuyamat/pace/internal/runner"
+)
+
+var graphCommand = gear.NewExecutableCommand("graph", "Export the task and hook graph as DOT, Mermaid or JSON").
+	Flags(
+		gear.NewStringFlag("format", "", "Output format: dot, mermaid or json", "dot"),
+		gear.NewBoolFlag("reverse", "r", "Show the tasks that depend on the given task or use the given hook", false)).
+	Args(
+		gear.NewStringArg("task", "Task or hook to start from, the whole graph if omitted").AsOptional()).
+	Handler(graphHandler)
+
+func init() {
+	RootCommand.AddChild(graphCommand)
+}
+
+func graphHandler(ctx *gear.Context, args gear.ValidatedArgs) error {
+	format := args.FlagString("format")
+	if format != "dot" && format != "mermaid" && format != "json" {
+		return fmt.Errorf("unknown graph format '%s', expected dot, mermaid or json", format)
+	}
+
+	name := args.String("task")
+	reverse := args.FlagBool("reverse")
+	if reverse && name == "" {
+		return fmt.Errorf("--reverse requires a task or hook name")
+	}
+
+	// The output is meant to be piped or pasted, so keep warnings printed
+	// while loading out of it.
+	logger.Default.SetEnabled(false)
+	defer logger.Default.SetEnabled(true)
+
+	cfg, err := config.GetConfig()
+	if err != nil {
+		return err
+	}
+
+	graph, err := runner.BuildGraph(cfg, name, reverse)
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case "dot":
+		logger.Printf("%s", graphDOT(graph))
+	case "mermaid":
+		logger.Printf("%s", graphMermaid(graph))
+	case "json":
+		data, err := json.MarshalIndent(graph, "", "  ")
+		if err != nil {
+			return err
+		}
+		logger.Println(string(data))
+	}
+	return nil
+}
+
+// graphDOT renders the graph for Graphviz. Hooks and the edges to them are
+// dashed; the default task is drawn bold.
+func graphDOT(graph *runner.Graph) string {
+	var b strings.Builder
+	b.WriteString("digraph pace {\n")
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [shape=box];\n")
+
+	for _, node := range graph.Nodes {
+		attrs := []string{"label=" + strconv.Quote(node.Name)}
+		if node.Kind == runner.NodeHook {
+			attrs = append(attrs, "shape=ellipse", "style=dashed")
+		} else if node.Default {
+			attrs = append(attrs, "style=bold")
+		}
+		if node.ID == graph.Root {
+			attrs = append(attrs, "color=blue")
+		}
+		fmt.Fprintf(&b, "  %s [%s];\n", strconv.Quote(node.ID), strings.Join(attrs, ", "))
+	}
+
+	for _, edge := range graph.Edges {
+		attrs := []string{"label=" + strconv.Quote(edge.Type)}
+		if edge.Type != runner.EdgeDependsOn {
+			attrs = append(attrs, "style=dashed")
+		}
+		fmt.Fprintf(&b, "  %s -> %s [%s];\n", strconv.Quote(edge.From), strconv.Quote(edge.To), strings.Join(attrs, ", "))
+	}
+
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// graphMermaid renders the graph as a Mermaid flowchart. Node names are not
+// valid Mermaid IDs in general, so nodes are numbered and labelled.
+func graphMermaid(graph *runner.Graph) string {
+	ids := make(map[string]string, len(graph.Nodes))
+	var b strings.Builder
+	b.WriteString("graph LR\n")
+
+	for i, node := range graph.Nodes {
+		id := fmt.Sprintf("n%d", i)
+		ids[node.ID] = id
+		label := strings.ReplaceAll(node.Name, `"`, "#quot;")
+		if node.Kind == runner.NodeHook {
+			fmt.Fprintf(&b, "  %s([\"%s\"])\n", id, label)
+		} else {
+			fmt.Fprintf(&b, "  %s[\"%s\"]\n", id, label)
+		}
+	}
+
+	for _, edge := range graph.Edges {
+		arrow := "-.->"
+		if edge.Type == runner.EdgeDependsOn {
+			arrow = "-->"
+		}
+		fmt.Fprintf(&b, "  %s %s|%s| %s\n", ids[edge.From], arrow, edge.Type, ids[edge.To])
+	}
+
+	return b.String()
+}
@@ -6,6 +6,7 @@ import (
 	gear "github.com/azuyamat/gear/command"
 	"github.com/azuyamat/pace/internal/config"
 	"github.com/azuyamat/pace/internal/logger"
+	"github.com/azuyamat/pace/internal/runner"
 )
 
 var listCommand = gear.NewExecutableCommand("list", "List all available tasks and their details").
@@ -49,12 +50,11 @@ func printTaskList(cfg *config.Config) {
 		if cfg.DefaultTask == name {
 			defaultMarker = " (default)"
 		}
-
-		if task.Description != "" {
-			logger.Printf("  %-20s %s%s\n", name, task.Description, defaultMarker)
-		} else {
-			logger.Printf("  %-20s %s%s\n", name, task.Command, defaultMarker)
+		if task.Extends != "" {
+			defaultMarker += " (extends " + task.Extends + ")"
 		}
+
+		logger.Printf("  %-20s %s%s\n", name, runner.TaskSummary(task), defaultMarker)
 	}
 
 	if len(cfg.Aliases) > 0 {
@@ -70,6 +70,24 @@ func printTaskList(cfg *config.Config) {
 		}
 	}
 
+	if len(cfg.Templates) > 0 {
+		logger.Println("\nTemplates:")
+		templateNames := make([]string, 0, len(cfg.Templates))
+		for name := range cfg.Templates {
+			templateNames = append(templateNames, name)
+		}
+		sort.Strings(templateNames)
+
+		for _, name := range templateNames {
+			template := cfg.Templates[name]
+			if template.Extends != "" {
+				logger.Printf("  %-20s extends %s\n", name, template.Extends)
+			} else {
+				logger.Printf("  %s\n", name)
+			}
+		}
+	}
+
 	if len(cfg.Hooks) > 0 {
 		logger.Println("\nAvailable hooks:")
 		hookNames := make([]string, 0, len(cfg.Hooks))
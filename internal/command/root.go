@@ -1,7 +1,239 @@
 package command
 
 import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
 	gear "github.com/azuyamat/gear/command"
+	"github.com/azuyamat/pace/internal/config"
+	"github.com/azuyamat/pace/internal/runner"
 )
 
-var RootCommand = gear.NewRootCommand("pace", "A task runner tool")
+var RootCommand = gear.NewRootCommand("pace", "A task runner tool").
+	GlobalFlags(globalFlags...)
+
+// globalFlags are accepted by every command, before or after its name. They
+// are applied by Execute before the command line is parsed.
+var globalFlags = []gear.Flag{
+	gear.NewStringFlag("file", "f", "Config file to use instead of searching this directory and its parents", ""),
+}
+
+// taskCommandFlags lists the flags of commands that take a task name followed
+// by task arguments. Anything after the task name that is not one of these
+// flags is passed through to the task untouched.
+var taskCommandFlags = map[string][]gear.Flag{
+	"run":   runFlags,
+	"watch": watchFlags,
+}
+
+// optionalFlagValues holds the value used when one of these flags is given
+// without a duration after it, as in a bare --poll.
+var optionalFlagValues = map[string]string{
+	"poll": "true",
+}
+
+func Execute(args []string) error {
+	args, file, err := extractFileFlag(args)
+	if err != nil {
+		return err
+	}
+	if file != "" {
+		config.SetConfigFile(file)
+	}
+	if len(args) == 0 && runner.IsInteractive() {
+		// A bare "pace" in a project opens the task picker, elsewhere it
+		// shows help.
+		if _, err := config.FindConfigFile(); err == nil {
+			args = []string{"run", "--interactive"}
+		}
+	}
+	return RootCommand.Run(normalizeTaskArgs(args))
+}
+
+// extractFileFlag removes the --file flag from the flags of pace and of the
+// command, and returns its value. Arguments after the task name of run and
+// watch belong to the task and are left alone.
+func extractFileFlag(args []string) ([]string, string, error) {
+	result := make([]string, 0, len(args))
+	file := ""
+	command := ""
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			result = append(result, args[i:]...)
+			break
+		}
+		if len(arg) < 2 || arg[0] != '-' {
+			if _, takesTask := taskCommandFlags[command]; takesTask {
+				result = append(result, args[i:]...)
+				break
+			}
+			if command == "" {
+				command = arg
+			}
+			result = append(result, arg)
+			continue
+		}
+		if lookupFlag(globalFlags, arg) == nil {
+			result = append(result, arg)
+			if flag := lookupFlag(taskCommandFlags[command], arg); flag != nil && takesValue(flag, arg, args[i+1:]) {
+				i++
+				result = append(result, args[i])
+			}
+			continue
+		}
+		if _, value, ok := strings.Cut(arg, "="); ok {
+			file = value
+			continue
+		}
+		if i+1 >= len(args) {
+			return nil, "", fmt.Errorf("flag %s requires a config file path", arg)
+		}
+		i++
+		file = args[i]
+	}
+	return result, file, nil
+}
+
+// normalizeTaskArgs moves task arguments behind a "--" separator so that
+// flags meant for the task, such as --env=prod or --help, are not rejected
+// or swallowed by the command line parser.
+func normalizeTaskArgs(args []string) []string {
+	if len(args) == 0 {
+		return args
+	}
+	flags, ok := taskCommandFlags[args[0]]
+	if !ok {
+		return args
+	}
+
+	result := []string{args[0]}
+	passthrough := make([]string, 0)
+	taskSeen := false
+
+	for i := 1; i < len(args); i++ {
+		arg := args[i]
+
+		if arg == "--" {
+			if taskSeen {
+				passthrough = append(passthrough, args[i:]...)
+			} else {
+				passthrough = append(passthrough, args[i+1:]...)
+			}
+			break
+		}
+
+		if len(arg) > 1 && arg[0] == '-' {
+			if flag := lookupFlag(flags, arg); flag != nil {
+				if value, ok := optionalFlagValues[flag.Name()]; ok && !strings.Contains(arg, "=") &&
+					!takesValue(flag, arg, args[i+1:]) {
+					result = append(result, arg+"="+value)
+					continue
+				}
+				result = append(result, arg)
+				if takesValue(flag, arg, args[i+1:]) {
+					i++
+					result = append(result, args[i])
+				}
+				continue
+			}
+			if !taskSeen && (arg == "--help" || arg == "-h") {
+				result = append(result, arg)
+				continue
+			}
+			passthrough = append(passthrough, arg)
+			continue
+		}
+
+		if !taskSeen {
+			taskSeen = true
+			result = append(result, arg)
+			continue
+		}
+		passthrough = append(passthrough, arg)
+	}
+
+	if len(passthrough) > 0 {
+		result = append(result, "--")
+		result = append(result, passthrough...)
+	}
+	return result
+}
+
+// takesValue reports whether the flag written as arg takes the next
+// argument, rest[0], as its value.
+func takesValue(flag gear.Flag, arg string, rest []string) bool {
+	if flag.Expected() == gear.ValueTypeBool || strings.Contains(arg, "=") || len(rest) == 0 {
+		return false
+	}
+	if _, optional := optionalFlagValues[flag.Name()]; optional {
+		return isDuration(rest[0])
+	}
+	return true
+}
+
+func lookupFlag(flags []gear.Flag, arg string) gear.Flag {
+	name, _, _ := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+	long := strings.HasPrefix(arg, "--")
+	for _, flag := range flags {
+		if long && flag.Name() == name {
+			return flag
+		}
+		if !long && flag.Shorthand() != "" && flag.Shorthand() == name {
+			return flag
+		}
+	}
+	return nil
+}
+
+func isDuration(value string) bool {
+	_, err := time.ParseDuration(value)
+	return err == nil
+}
+
+// resolvePathArgs makes the task arguments that are paths relative to the
+// directory pace was started from absolute, since tasks run from the config
+// directory. An argument, or the value of --name=value, is taken for a path
+// only when it starts with ./ or ../, so that words are never rewritten.
+func resolvePathArgs(cfg *config.Config, args []string) []string {
+	if cfg.InvocationDir == "" || cfg.InvocationDir == cfg.Dir {
+		return args
+	}
+
+	resolved := make([]string, len(args))
+	for i, arg := range args {
+		if name, value, ok := strings.Cut(arg, "="); ok && strings.HasPrefix(name, "--") {
+			resolved[i] = name + "=" + resolvePathArg(cfg.InvocationDir, value)
+			continue
+		}
+		if strings.HasPrefix(arg, "-") {
+			resolved[i] = arg
+			continue
+		}
+		resolved[i] = resolvePathArg(cfg.InvocationDir, arg)
+	}
+	return resolved
+}
+
+func resolvePathArg(dir, arg string) string {
+	for _, prefix := range []string{".", ".."} {
+		if strings.HasPrefix(arg, prefix+"/") || strings.HasPrefix(arg, prefix+string(filepath.Separator)) {
+			return filepath.Join(dir, arg)
+		}
+	}
+	return arg
+}
+
+func hasHelpFlag(args []string) bool {
+	for _, arg := range args {
+		if arg == "--" {
+			return false
+		}
+		if arg == "--help" || arg == "-h" {
+			return true
+		}
+	}
+	return false
+}
@@ -1,14 +1,29 @@
 package command
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
 
 	gear "github.com/azuyamat/gear/command"
 	"github.com/azuyamat/pace/internal/config"
+	"github.com/azuyamat/pace/internal/logger"
 	"github.com/azuyamat/pace/internal/runner"
 )
 
+var runFlags = []gear.Flag{
+	gear.NewBoolFlag("dry-run", "n", "Show what would run, including evaluated variables, without executing", false),
+	gear.NewBoolFlag("all", "", "Run the task in the workspace root and every member that defines it", false),
+	gear.NewBoolFlag("affected", "", "Only run tasks whose inputs changed according to git", false),
+	gear.NewStringFlag("base", "", "Git revision --affected compares against", "origin/main"),
+	gear.NewBoolFlag("interactive", "i", "Pick the task and its arguments from a searchable list", false),
+}
+
 var runCommand = gear.NewExecutableCommand("run", "Run a specified task").
+	Flags(runFlags...).
 	Args(
 		gear.NewStringArg("task", "Name of the task to run").AsOptional(),
 		gear.NewStringArg("args", "Arguments to pass to the task").AsOptional().AsVariadic()).
@@ -24,18 +39,114 @@ func runHandler(ctx *gear.Context, args gear.ValidatedArgs) error {
 		return err
 	}
 	taskName := args.String("task")
-	runner := runner.NewRunner(config)
+	extraArgs := args.VariadicStrings("args")
+
+	interactive := args.FlagBool("interactive")
+	if interactive && !runner.IsInteractive() {
+		return fmt.Errorf("--interactive needs a terminal")
+	}
+	if interactive || (taskName == "" && config.DefaultTask == "" && !args.FlagBool("all") && runner.IsInteractive()) {
+		var choice runner.TaskChoice
+		if task, exists := config.GetTaskOrDefault(taskName); exists && taskName != "" {
+			choice, err = runner.PromptTaskArgs(task)
+		} else {
+			choice, err = runner.PickTask(config)
+		}
+		if errors.Is(err, runner.ErrPickCancelled) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := runner.RememberChoice(choice); err != nil {
+			logger.Warning("Failed to remember %q: %v", choice.Task, err)
+		}
+		logger.Info("Running %s", choice)
+		taskName = choice.Task
+		extraArgs = append(choice.Args, extraArgs...)
+	}
+	extraArgs = resolvePathArgs(config, extraArgs)
+
+	if args.FlagBool("all") {
+		return runAll(config, taskName, extraArgs, args)
+	}
 
 	task, exists := config.GetTaskOrDefault(taskName)
 	if !exists {
 		return fmt.Errorf("task '%s' not found", taskName)
 	}
 
-	extraArgs := args.VariadicStrings("args")
+	if hasHelpFlag(extraArgs) {
+		logger.Println(runner.TaskUsage(task))
+		return nil
+	}
 
 	if task.Watch {
-		return Watch(config, []string{taskName}, extraArgs...)
+		return Watch(config, "", []string{taskName}, extraArgs...)
+	}
+
+	// Services are started in their own process group, so interrupts are
+	// handled here to stop them before exiting.
+	runCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	r, err := newRunner(config, args)
+	if err != nil {
+		return err
+	}
+	defer r.StopServices()
+
+	if err := r.RunTaskWithContext(runCtx, task, extraArgs...); err != nil {
+		return err
 	}
 
-	return runner.RunTask(task, extraArgs...)
+	if task.Service && !r.DryRun {
+		logger.Info("Service %q is running (press Ctrl+C to stop)", task.Name)
+		return r.WaitService(runCtx, task.Name)
+	}
+	return nil
+}
+
+// runAll runs a task across the workspace, in dependency order.
+func runAll(cfg *config.Config, taskName string, extraArgs []string, args gear.ValidatedArgs) error {
+	if taskName == "" {
+		taskName = cfg.DefaultTask
+	}
+	tasks, err := runner.WorkspaceTasks(cfg, taskName)
+	if err != nil {
+		return err
+	}
+
+	runCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	r, err := newRunner(cfg, args)
+	if err != nil {
+		return err
+	}
+	defer r.StopServices()
+
+	for _, task := range tasks {
+		if err := r.RunTaskWithContext(runCtx, task, extraArgs...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// newRunner creates a runner configured by the run command's flags.
+func newRunner(cfg *config.Config, args gear.ValidatedArgs) (*runner.Runner, error) {
+	r := runner.NewRunner(cfg)
+	r.DryRun = args.FlagBool("dry-run")
+
+	if args.FlagBool("affected") {
+		base := args.FlagString("base")
+		changed, err := runner.ChangedFiles(base)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list changed files: %v", err)
+		}
+		logger.Info("%s changed since %s", fileCount(len(changed)), base)
+		r.ChangedFiles = changed
+	}
+	return r, nil
 }
@@ -0,0 +1,25 @@
+package command
+
+import (
+	"encoding/json"
+
+	gear "github.com/azuyamat/gear/command"
+	"github.com/azuyamat/pace/internal/config"
+	"github.com/azuyamat/pace/internal/logger"
+)
+
+var schemaCommand = gear.NewExecutableCommand("schema", "Print the JSON Schema of YAML, TOML and JSON config files").
+	Handler(schemaHandler)
+
+func init() {
+	RootCommand.AddChild(schemaCommand)
+}
+
+func schemaHandler(ctx *gear.Context, args gear.ValidatedArgs) error {
+	data, err := json.MarshalIndent(config.Schema(), "", "  ")
+	if err != nil {
+		return err
+	}
+	logger.Println(string(data))
+	return nil
+}
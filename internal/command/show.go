@@ -0,0 +1,174 @@
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	gear "github.com/azuyamat/gear/command"
+	"github.com/azuyamat/pace/internal/config"
+	"github.com/azuyamat/pace/internal/logger"
+	"github.com/azuyamat/pace/internal/runner"
+)
+
+var showCommand = gear.NewExecutableCommand("show", "Show a task after templates, variables and imports are resolved").
+	Flags(
+		gear.NewBoolFlag("json", "", "Print the task as JSON", false)).
+	Args(
+		gear.NewStringArg("task", "Name or alias of the task to show").AsOptional()).
+	Handler(showHandler)
+
+func init() {
+	RootCommand.AddChild(showCommand)
+}
+
+func showHandler(ctx *gear.Context, args gear.ValidatedArgs) error {
+	jsonOutput := args.FlagBool("json")
+	if jsonOutput {
+		// Keep warnings printed while loading out of the JSON document.
+		logger.Default.SetEnabled(false)
+		defer logger.Default.SetEnabled(true)
+	}
+
+	cfg, err := config.GetConfig()
+	if err != nil {
+		return err
+	}
+	taskName := args.String("task")
+
+	task, exists := cfg.GetTaskOrDefault(taskName)
+	if !exists {
+		return fmt.Errorf("task '%s' not found", taskName)
+	}
+
+	details, err := runner.DescribeTask(cfg, task)
+	if err != nil {
+		return err
+	}
+
+	if jsonOutput {
+		data, err := json.MarshalIndent(details, "", "  ")
+		if err != nil {
+			return err
+		}
+		logger.Println(string(data))
+		return nil
+	}
+
+	printTaskDetails(details)
+	return nil
+}
+
+func printTaskDetails(details *runner.TaskDetails) {
+	title := "Task " + details.Name
+	if details.Alias != "" {
+		title += " [" + details.Alias + "]"
+	}
+	if details.Extends != "" {
+		title += " (extends " + details.Extends + ")"
+	}
+	logger.Println(title)
+
+	field := func(label, value string) {
+		if value != "" {
+			logger.Printf("  %-14s %s\n", label, value)
+		}
+	}
+	list := func(label string, values []string) {
+		for i, value := range values {
+			if i > 0 {
+				label = ""
+			}
+			logger.Printf("  %-14s %s\n", label, value)
+		}
+	}
+
+	field("Defined in", details.Source)
+	field("Description", details.Description)
+	field("Command", details.Command)
+	field("Working dir", details.WorkingDir)
+	field("Depends on", strings.Join(details.DependsOn, ", "))
+
+	inputs := make([]string, 0, len(details.Inputs))
+	total := 0
+	for _, input := range details.Inputs {
+		inputs = append(inputs, fmt.Sprintf("%s (%s)", input.Pattern, fileCount(len(input.Files))))
+		total += len(input.Files)
+	}
+	if len(details.Inputs) > 1 {
+		inputs = append(inputs, fmt.Sprintf("%s in total", fileCount(total)))
+	}
+	list("Inputs", inputs)
+	list("Outputs", details.Outputs)
+	list("Watch inputs", details.WatchInputs)
+
+	hooks := make([]string, 0, len(details.Hooks))
+	for _, hook := range details.Hooks {
+		hooks = append(hooks, fmt.Sprintf("%-10s %s: %s (%s)", hook.Stage, hook.Name, hook.Command, hook.Source))
+	}
+	list("Hooks", hooks)
+
+	argLines := make([]string, 0, len(details.Args))
+	for _, arg := range details.Args {
+		line := fmt.Sprintf("%s %s", arg.Name, arg.Type)
+		switch {
+		case arg.HasDefault:
+			line += fmt.Sprintf(" (default %q)", arg.Default)
+		case arg.Required:
+			line += " (required)"
+		}
+		if len(arg.Choices) > 0 {
+			line += " choices " + strings.Join(arg.Choices, ", ")
+		}
+		argLines = append(argLines, line)
+	}
+	list("Args", argLines)
+
+	list("Variables", sortedPairs(details.Variables))
+	list("Env", sortedPairs(details.Env))
+
+	settings := make([]string, 0, len(details.Settings))
+	for key, value := range details.Settings {
+		if value == false || value == 0 {
+			continue
+		}
+		settings = append(settings, fmt.Sprintf("%s %v", key, value))
+	}
+	sort.Strings(settings)
+	list("Settings", settings)
+
+	if details.Ready != nil {
+		ready := make([]string, 0, 4)
+		if details.Ready.Port != 0 {
+			ready = append(ready, fmt.Sprintf("port %d", details.Ready.Port))
+		}
+		if details.Ready.HTTP != "" {
+			ready = append(ready, "http "+details.Ready.HTTP)
+		}
+		if details.Ready.Log != "" {
+			ready = append(ready, fmt.Sprintf("log %q", details.Ready.Log))
+		}
+		if details.Ready.Command != "" {
+			ready = append(ready, "command "+details.Ready.Command)
+		}
+		ready = append(ready, fmt.Sprintf("timeout %s, interval %s", details.Ready.Timeout, details.Ready.Interval))
+		list("Ready", ready)
+	}
+}
+
+func fileCount(n int) string {
+	if n == 1 {
+		return "1 file"
+	}
+	return fmt.Sprintf("%d files", n)
+}
+
+func sortedPairs(values map[string]string) []string {
+	pairs := make([]string, 0, len(values))
+	for key, value := range values {
+		pairs = append(pairs, key+"="+value)
+	}
+	sort.Strings(pairs)
+	return pairs
+}
@@ -0,0 +1,118 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	gear "github.com/azuyamat/gear/command"
+	"github.com/azuyamat/pace/internal/config"
+	"github.com/azuyamat/pace/internal/logger"
+	"github.com/azuyamat/pace/internal/runner"
+)
+
+var upCommand = gear.NewExecutableCommand("up", "Start a group of long-running tasks and keep them running").
+	Args(gear.NewStringArg("group", "Name of the group to start").AsOptional()).
+	Handler(upHandler)
+
+var downCommand = gear.NewExecutableCommand("down", "Stop groups started with pace up").
+	Args(gear.NewStringArg("group", "Name of the group to stop, all groups if omitted").AsOptional()).
+	Handler(downHandler)
+
+var psCommand = gear.NewExecutableCommand("ps", "List the processes of groups started with pace up").
+	Handler(psHandler)
+
+func init() {
+	RootCommand.AddChild(upCommand)
+	RootCommand.AddChild(downCommand)
+	RootCommand.AddChild(psCommand)
+}
+
+func upHandler(ctx *gear.Context, args gear.ValidatedArgs) error {
+	cfg, err := config.GetConfig()
+	if err != nil {
+		return err
+	}
+
+	group := args.String("group")
+	if group == "" {
+		if group, err = runner.DefaultGroup(cfg); err != nil {
+			return err
+		}
+	}
+
+	supervisor, err := runner.NewSupervisor(cfg, group)
+	if err != nil {
+		return err
+	}
+
+	upCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return supervisor.Up(upCtx)
+}
+
+func downHandler(ctx *gear.Context, args gear.ValidatedArgs) error {
+	states, err := runner.ReadGroupStates()
+	if err != nil {
+		return err
+	}
+
+	group := args.String("group")
+	stopped := 0
+	for _, state := range states {
+		if group != "" && state.Group != group {
+			continue
+		}
+		logger.Info("Stopping group %q...", state.Group)
+		if err := runner.StopGroup(state); err != nil {
+			return err
+		}
+		logger.Success("Group %q stopped", state.Group)
+		stopped++
+	}
+
+	if stopped == 0 {
+		if group != "" {
+			return fmt.Errorf("group %q is not up", group)
+		}
+		logger.Info("No groups are up")
+	}
+	return nil
+}
+
+func psHandler(ctx *gear.Context, args gear.ValidatedArgs) error {
+	states, err := runner.ReadGroupStates()
+	if err != nil {
+		return err
+	}
+
+	if len(states) == 0 {
+		logger.Info("No groups are up")
+		return nil
+	}
+
+	logger.Printf("%-12s %-20s %-8s %-12s %-9s %s\n", "GROUP", "TASK", "PID", "STATUS", "RESTARTS", "UPTIME")
+	for _, state := range states {
+		supervised := state.Alive()
+		for _, process := range state.Processes {
+			status := process.Status
+			uptime := "-"
+			switch {
+			case !supervised:
+				status = "stale"
+			case status == runner.StatusRunning && !process.Alive():
+				status = runner.StatusExited
+			case status == runner.StatusRunning:
+				uptime = time.Since(process.Started).Round(time.Second).String()
+			}
+			logger.Printf("%-12s %-20s %-8d %-12s %-9d %s\n", state.Group, process.Task, process.PID, status, process.Restarts, uptime)
+		}
+		if !supervised {
+			logger.Warning("The supervisor of group %q is no longer running, run 'pace down %s' to clean up", state.Group, state.Group)
+		}
+	}
+	return nil
+}
@@ -6,13 +6,19 @@ import (
 	gear "github.com/azuyamat/gear/command"
 	"github.com/azuyamat/pace/internal/config"
 	"github.com/azuyamat/pace/internal/logger"
+	"github.com/azuyamat/pace/internal/models"
 	"github.com/azuyamat/pace/internal/runner"
 )
 
-var watchCommand = gear.NewExecutableCommand("watch", "Watch a task's inputs and re-run it on changes").
+var watchFlags = []gear.Flag{
+	gear.NewStringFlag("poll", "", "Poll for changes at the given interval instead of using file system events", ""),
+}
+
+var watchCommand = gear.NewExecutableCommand("watch", "Watch tasks' inputs and re-run them on changes").
+	Flags(watchFlags...).
 	Args(
 		gear.NewStringArg("task", "Name of the task to watch"),
-		gear.NewStringArg("args", "Arguments to pass to the task").AsOptional().AsVariadic()).
+		gear.NewStringArg("args", "More tasks to watch, or arguments to pass to a single task").AsOptional().AsVariadic()).
 	Handler(watchHandler)
 
 func init() {
@@ -24,36 +30,80 @@ func watchHandler(ctx *gear.Context, args gear.ValidatedArgs) error {
 	if err != nil {
 		return err
 	}
-	taskName := args.String("task")
+
+	// Leading arguments that name tasks are watched together; the rest are
+	// passed to the task.
+	taskNames := []string{args.String("task")}
 	extraArgs := args.VariadicStrings("args")
-	return Watch(config, []string{taskName}, extraArgs...)
+	for len(extraArgs) > 0 {
+		if _, isTask := config.Tasks[config.TaskName(extraArgs[0])]; !isTask {
+			break
+		}
+		taskNames = append(taskNames, extraArgs[0])
+		extraArgs = extraArgs[1:]
+	}
+
+	return Watch(config, args.FlagString("poll"), taskNames, resolvePathArgs(config, extraArgs)...)
 }
 
-func Watch(cfg *config.Config, args []string, extraArgs ...string) error {
-	if len(args) < 1 {
+// Watch runs the given tasks in watch mode. poll is the --poll flag value;
+// when empty the watch_poll global decides whether to poll.
+func Watch(cfg *config.Config, poll string, taskNames []string, extraArgs ...string) error {
+	if len(taskNames) < 1 {
 		logger.Error("No task name provided for watch command")
 		return fmt.Errorf("no task name provided for watch command")
 	}
+	if len(taskNames) > 1 && len(extraArgs) > 0 {
+		return fmt.Errorf("task arguments can only be passed when watching a single task")
+	}
 
-	taskName := args[0]
+	targets := make([]runner.WatchTarget, 0, len(taskNames))
+	seen := make(map[string]bool)
+	for _, taskName := range taskNames {
+		taskName = cfg.TaskName(taskName)
+		if seen[taskName] {
+			continue
+		}
+		seen[taskName] = true
 
-	if alias, exists := cfg.Aliases[taskName]; exists {
-		taskName = alias
-	}
+		task, exists := cfg.Tasks[taskName]
+		if !exists {
+			logger.Error("Task %q not found in configuration", taskName)
+			return fmt.Errorf("task %q not found in configuration", taskName)
+		}
+
+		patterns := task.WatchInputs
+		if len(patterns) == 0 {
+			patterns = task.Inputs
+		}
+		if len(patterns) == 0 {
+			logger.Warning("Task %q has no inputs defined for watching", taskName)
+			return fmt.Errorf("task %q has no inputs defined for watching", taskName)
+		}
+
+		options := models.DefaultWatchOptions()
+		if task.WatchOptions != nil {
+			options = *task.WatchOptions
+		}
 
-	task, exists := cfg.Tasks[taskName]
-	if !exists {
-		logger.Error("Task %q not found in configuration", taskName)
-		return fmt.Errorf("task %q not found in configuration", taskName)
+		targets = append(targets, runner.WatchTarget{
+			Task:      task,
+			Patterns:  append([]string{}, patterns...),
+			ExtraArgs: extraArgs,
+			Options:   options,
+		})
 	}
 
-	if len(task.Inputs) == 0 {
-		logger.Warning("Task %q has no inputs defined for watching", taskName)
-		return fmt.Errorf("task %q has no inputs defined for watching", taskName)
+	if poll == "" {
+		poll = cfg.Globals["watch_poll"]
+	}
+	interval, err := runner.ParsePollInterval(poll)
+	if err != nil {
+		return fmt.Errorf("invalid poll interval %q: %v", poll, err)
 	}
 
-	r := runner.NewRunner(cfg)
-	w := runner.NewWatcher(r, task, task.Inputs, extraArgs)
+	w := runner.NewWatcher(cfg, targets)
+	w.PollInterval = interval
 
 	if err := w.Watch(); err != nil {
 		return err
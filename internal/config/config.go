@@ -6,11 +6,16 @@ import (
 	"strings"
 
 	"github.com/azuyamat/pace/internal/config/loading"
+	"github.com/azuyamat/pace/internal/config/parsing"
 )
 
 type Config = loading.Config
 
-var ConfigFile = loading.ConfigFile
+// SetConfigFile makes GetConfig load path instead of searching for a config
+// file.
+func SetConfigFile(path string) {
+	loading.ConfigFile = path
+}
 
 func NewDefaultConfig() *Config {
 	return loading.NewDefaultConfig()
@@ -24,6 +29,28 @@ func ParseFile(path string) (*Config, error) {
 	return loading.ParseFile(path)
 }
 
+// FindConfigFile returns the config file GetConfig would load.
+func FindConfigFile() (string, error) {
+	return loading.FindConfigFile()
+}
+
+// ParseUnprocessed parses a config file as written, without loading its
+// imports or resolving templates and variables.
+func ParseUnprocessed(path string) (*Config, error) {
+	return loading.ParseUnprocessed(path)
+}
+
+// Schema returns a JSON Schema of YAML, TOML and JSON config files.
+func Schema() map[string]any {
+	return parsing.Schema()
+}
+
+// UpdateImports fetches the git imports of the config file again and
+// rewrites its lock file.
+func UpdateImports() (before, after []loading.LockedImport, err error) {
+	return loading.UpdateImports()
+}
+
 func UpdateGitignore(projectPath string) error {
 	gitignorePath := fmt.Sprintf("%s/.gitignore", projectPath)
 
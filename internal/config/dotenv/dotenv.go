@@ -0,0 +1,307 @@
+package dotenv
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"strings"
+)
+
+// LookupFunc resolves a variable that is not defined in the file being parsed.
+type LookupFunc func(name string) (string, bool)
+
+// LoadFiles parses the given files in order, later files overriding earlier
+// ones. References are resolved against values from earlier files first and
+// then lookup. Files that do not exist are skipped.
+func LoadFiles(paths []string, lookup LookupFunc) (map[string]string, error) {
+	result := make(map[string]string)
+
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to read env file %q: %v", path, err)
+		}
+
+		layer := maps.Clone(result)
+		values, err := Parse(string(data), func(name string) (string, bool) {
+			if value, ok := layer[name]; ok {
+				return value, true
+			}
+			if lookup != nil {
+				return lookup(name)
+			}
+			return "", false
+		})
+		if err != nil {
+			return nil, fmt.Errorf("%s:%v", path, err)
+		}
+		maps.Copy(result, values)
+	}
+
+	return result, nil
+}
+
+// Parse reads dotenv formatted content. It supports comments, an optional
+// "export" prefix, single-quoted literal values, double-quoted values with
+// escape sequences, values spanning several lines inside quotes, and ${VAR}
+// expansion in unquoted and double-quoted values.
+func Parse(content string, lookup LookupFunc) (map[string]string, error) {
+	p := &parser{
+		input:  strings.ReplaceAll(content, "\r\n", "\n"),
+		line:   1,
+		lookup: lookup,
+		values: make(map[string]string),
+	}
+	if err := p.parse(); err != nil {
+		return nil, err
+	}
+	return p.values, nil
+}
+
+type parser struct {
+	input  string
+	pos    int
+	line   int
+	lookup LookupFunc
+	values map[string]string
+}
+
+func (p *parser) parse() error {
+	for {
+		p.skipBlank()
+		if p.atEnd() {
+			return nil
+		}
+
+		if p.peek() == '#' {
+			p.skipLine()
+			continue
+		}
+
+		key, err := p.parseKey()
+		if err != nil {
+			return err
+		}
+
+		value, err := p.parseValue()
+		if err != nil {
+			return err
+		}
+		p.values[key] = value
+	}
+}
+
+func (p *parser) parseKey() (string, error) {
+	if strings.HasPrefix(p.input[p.pos:], "export") && p.pos+6 < len(p.input) && isSpace(p.input[p.pos+6]) {
+		p.pos += 6
+		p.skipSpaces()
+	}
+
+	start := p.pos
+	for !p.atEnd() && isKeyChar(p.peek()) {
+		p.pos++
+	}
+	key := p.input[start:p.pos]
+	if key == "" {
+		return "", p.errorf("expected variable name but got %q", p.peek())
+	}
+
+	p.skipSpaces()
+	if p.atEnd() || p.peek() != '=' {
+		return "", p.errorf("expected '=' after %s", key)
+	}
+	p.pos++
+	p.skipSpaces()
+
+	return key, nil
+}
+
+func (p *parser) parseValue() (string, error) {
+	if p.atEnd() {
+		return "", nil
+	}
+
+	switch p.peek() {
+	case '\'':
+		raw, err := p.readQuoted('\'')
+		if err != nil {
+			return "", err
+		}
+		return raw, p.expectLineEnd()
+	case '"':
+		raw, err := p.readQuoted('"')
+		if err != nil {
+			return "", err
+		}
+		return p.expand(raw, true), p.expectLineEnd()
+	}
+
+	start := p.pos
+	for !p.atEnd() && p.peek() != '\n' {
+		if p.peek() == '#' && p.pos > start && isSpace(p.input[p.pos-1]) {
+			break
+		}
+		p.pos++
+	}
+	raw := strings.TrimSpace(p.input[start:p.pos])
+	p.skipLine()
+	return p.expand(raw, false), nil
+}
+
+// readQuoted returns the text between the opening quote at the current
+// position and its closing quote, keeping escape sequences intact.
+func (p *parser) readQuoted(quote byte) (string, error) {
+	startLine := p.line
+	p.pos++
+	start := p.pos
+
+	for !p.atEnd() {
+		char := p.peek()
+		if char == '\\' && quote == '"' && p.pos+1 < len(p.input) {
+			if p.input[p.pos+1] == '\n' {
+				p.line++
+			}
+			p.pos += 2
+			continue
+		}
+		if char == '\n' {
+			p.line++
+		}
+		if char == quote {
+			raw := p.input[start:p.pos]
+			p.pos++
+			return raw, nil
+		}
+		p.pos++
+	}
+
+	return "", fmt.Errorf("%d: unterminated %c-quoted value", startLine, quote)
+}
+
+func (p *parser) expectLineEnd() error {
+	p.skipSpaces()
+	if p.atEnd() || p.peek() == '\n' || p.peek() == '#' {
+		p.skipLine()
+		return nil
+	}
+	return p.errorf("unexpected %q after quoted value", p.peek())
+}
+
+// expand substitutes ${VAR}, ${VAR:-default} and $VAR references. Escape
+// sequences are only interpreted in double-quoted values.
+func (p *parser) expand(raw string, escapes bool) string {
+	var builder strings.Builder
+
+	for i := 0; i < len(raw); i++ {
+		char := raw[i]
+
+		if escapes && char == '\\' && i+1 < len(raw) {
+			i++
+			switch raw[i] {
+			case 'n':
+				builder.WriteByte('\n')
+			case 'r':
+				builder.WriteByte('\r')
+			case 't':
+				builder.WriteByte('\t')
+			case '\n':
+			default:
+				builder.WriteByte(raw[i])
+			}
+			continue
+		}
+
+		if char != '$' || i+1 >= len(raw) {
+			builder.WriteByte(char)
+			continue
+		}
+
+		if raw[i+1] == '{' {
+			end := strings.IndexByte(raw[i:], '}')
+			if end < 0 {
+				builder.WriteByte(char)
+				continue
+			}
+			name, fallback, hasFallback := strings.Cut(raw[i+2:i+end], ":-")
+			value, ok := p.resolve(name)
+			if !ok || (hasFallback && value == "") {
+				value = fallback
+			}
+			builder.WriteString(value)
+			i += end
+			continue
+		}
+
+		end := i + 1
+		for end < len(raw) && isNameChar(raw[end]) {
+			end++
+		}
+		if end == i+1 {
+			builder.WriteByte(char)
+			continue
+		}
+		value, _ := p.resolve(raw[i+1 : end])
+		builder.WriteString(value)
+		i = end - 1
+	}
+
+	return builder.String()
+}
+
+func (p *parser) resolve(name string) (string, bool) {
+	if value, ok := p.values[name]; ok {
+		return value, true
+	}
+	if p.lookup != nil {
+		return p.lookup(name)
+	}
+	return "", false
+}
+
+func (p *parser) skipBlank() {
+	for !p.atEnd() && (isSpace(p.peek()) || p.peek() == '\n') {
+		if p.peek() == '\n' {
+			p.line++
+		}
+		p.pos++
+	}
+}
+
+func (p *parser) skipSpaces() {
+	for !p.atEnd() && isSpace(p.peek()) {
+		p.pos++
+	}
+}
+
+func (p *parser) skipLine() {
+	for !p.atEnd() && p.peek() != '\n' {
+		p.pos++
+	}
+}
+
+func (p *parser) atEnd() bool {
+	return p.pos >= len(p.input)
+}
+
+func (p *parser) peek() byte {
+	return p.input[p.pos]
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	return fmt.Errorf("%d: %s", p.line, fmt.Sprintf(format, args...))
+}
+
+func isSpace(char byte) bool {
+	return char == ' ' || char == '\t'
+}
+
+func isKeyChar(char byte) bool {
+	return isNameChar(char) || char == '.' || char == '-'
+}
+
+func isNameChar(char byte) bool {
+	return (char >= 'a' && char <= 'z') || (char >= 'A' && char <= 'Z') || (char >= '0' && char <= '9') || char == '_'
+}
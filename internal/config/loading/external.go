@@ -0,0 +1,349 @@
+package loading
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/azuyamat/pace/internal/config/types"
+	"github.com/azuyamat/pace/internal/models"
+)
+
+// loadExternal exposes the scripts, targets or aliases of a file of another
+// tool as tasks. The tasks run the tool itself, in the file's directory.
+func loadExternal(kind types.ImportKind, path string) (*Config, error) {
+	if isGitImport(path) {
+		return nil, fmt.Errorf("%s cannot import %q, only local files are supported", kind.Keyword(), path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := types.NewConfig()
+	switch kind {
+	case types.ImportScripts:
+		err = loadScripts(cfg, path, data)
+	case types.ImportMake:
+		err = loadMakefile(cfg, path, data)
+	case types.ImportCargo:
+		err = loadCargoAliases(cfg, data)
+	default:
+		err = fmt.Errorf("unknown import kind '%s'", kind)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	setSourceFile(cfg, path)
+
+	dir := filepath.Dir(path)
+	if kind == types.ImportCargo && filepath.Base(dir) == ".cargo" {
+		// Cargo reads .cargo/config.toml from the directory above it.
+		dir = filepath.Dir(dir)
+	}
+	rebaseConfig(cfg, dir)
+	if dir != "." {
+		setWorkingDir(cfg, dir)
+	}
+	return cfg, nil
+}
+
+// externalTaskName turns a script or target name into a task name. A ':'
+// would refer to a workspace member, so "build:prod" becomes "build-prod".
+func externalTaskName(name string) string {
+	return strings.ReplaceAll(name, ":", "-")
+}
+
+// loadScripts reads the scripts of a package.json. A "preX" or "postX"
+// script next to a script "X" becomes a hook run before or after it, as npm
+// does, unless the package manager runs them itself.
+func loadScripts(cfg *Config, path string, data []byte) error {
+	var pkg struct {
+		Scripts map[string]string `json:"scripts"`
+	}
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return err
+	}
+
+	manager, runsHooks := packageManager(filepath.Dir(path))
+	run := func(script string) string {
+		return manager + " run " + script
+	}
+
+	isHook := func(name string) bool {
+		if runsHooks {
+			return false
+		}
+		for _, prefix := range []string{"pre", "post"} {
+			if target, found := strings.CutPrefix(name, prefix); found && target != "" {
+				if _, exists := pkg.Scripts[target]; exists {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	for _, name := range sortedKeys(pkg.Scripts) {
+		script := pkg.Scripts[name]
+		if isHook(name) {
+			hookName := externalTaskName(name)
+			cfg.Hooks[hookName] = models.Hook{
+				Name:        hookName,
+				Command:     run(name),
+				Description: script,
+			}
+			continue
+		}
+
+		task := models.Task{
+			Name:        externalTaskName(name),
+			Command:     run(name),
+			Description: script,
+		}
+		for _, hook := range []string{"pre" + name, "post" + name} {
+			if _, exists := pkg.Scripts[hook]; !exists || !isHook(hook) {
+				continue
+			}
+			if strings.HasPrefix(hook, "pre") {
+				task.Requires = append(task.Requires, externalTaskName(hook))
+			} else {
+				task.Triggers = append(task.Triggers, externalTaskName(hook))
+			}
+		}
+		if manager == "npm" && (len(task.Requires) > 0 || len(task.Triggers) > 0) {
+			// pace runs the hooks, npm would run them a second time.
+			task.Command += " --ignore-scripts"
+		}
+		cfg.Tasks[task.Name] = task
+	}
+	return nil
+}
+
+// packageManager returns the package manager of the project in dir from
+// its lock file, and whether it runs pre and post scripts without a way to
+// turn that off. Yarn 1 does, unlike later versions, so it is told apart by
+// the header of its lock file.
+func packageManager(dir string) (string, bool) {
+	if data, err := os.ReadFile(filepath.Join(dir, "yarn.lock")); err == nil {
+		return "yarn", bytes.Contains(data, []byte("yarn lockfile v1"))
+	}
+	for _, file := range []string{"bun.lockb", "bun.lock"} {
+		if _, err := os.Stat(filepath.Join(dir, file)); err == nil {
+			return "bun", true
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "pnpm-lock.yaml")); err == nil {
+		return "pnpm", false
+	}
+	return "npm", false
+}
+
+// makeRule is an explicit rule of a Makefile. A target may have several
+// rules, whose prerequisites add up.
+type makeRule struct {
+	target        string
+	prerequisites []string
+	description   string
+	line          int
+}
+
+// loadMakefile reads the explicit rules of a Makefile. Prerequisites that
+// are imported targets become dependencies, which make is told not to
+// remake, and those that are plain files become inputs. Targets named like
+// files, such as "main.o", are only imported when declared .PHONY.
+func loadMakefile(cfg *Config, path string, data []byte) error {
+	rules, phony, err := parseMakefile(data)
+	if err != nil {
+		return err
+	}
+
+	targets := make(map[string]bool, len(rules))
+	for _, rule := range rules {
+		targets[rule.target] = true
+	}
+	imported := func(target string) bool {
+		if strings.HasPrefix(target, ".") || strings.ContainsAny(target, "%$") {
+			return false
+		}
+		return phony[target] || !strings.ContainsAny(target, "./")
+	}
+
+	command := "make"
+	switch filepath.Base(path) {
+	case "Makefile", "makefile", "GNUmakefile":
+	default:
+		command += " -f " + filepath.Base(path)
+	}
+
+	for _, rule := range rules {
+		if !imported(rule.target) {
+			continue
+		}
+		name := externalTaskName(rule.target)
+		task, exists := cfg.Tasks[name]
+		if !exists {
+			task = models.Task{Name: name, Source: models.Source{Line: rule.line}}
+		}
+		if task.Description == "" {
+			task.Description = rule.description
+		}
+		for _, prerequisite := range rule.prerequisites {
+			switch {
+			case imported(prerequisite) && targets[prerequisite]:
+				if !slices.Contains(task.DependsOn, externalTaskName(prerequisite)) {
+					task.DependsOn = append(task.DependsOn, externalTaskName(prerequisite))
+				}
+			case !targets[prerequisite] && !phony[prerequisite] && !strings.ContainsAny(prerequisite, "%$"):
+				task.Inputs = append(task.Inputs, prerequisite)
+			}
+		}
+		cfg.Tasks[name] = task
+	}
+
+	for name, task := range cfg.Tasks {
+		args := []string{command}
+		for _, dependency := range task.DependsOn {
+			args = append(args, "-o", dependency)
+		}
+		task.Command = strings.Join(append(args, name), " ")
+		cfg.Tasks[name] = task
+	}
+	return nil
+}
+
+// parseMakefile returns the explicit rules of a Makefile in order, and the
+// targets declared .PHONY. Recipes, variables, conditionals and includes
+// are skipped. A rule's description is its trailing "## comment", or the
+// comment line right above it.
+func parseMakefile(data []byte) ([]makeRule, map[string]bool, error) {
+	rules := make([]makeRule, 0)
+	phony := make(map[string]bool)
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	lineNumber, comment, inDefine := 0, "", false
+	for scanner.Scan() {
+		lineNumber++
+		start := lineNumber
+		line := strings.TrimRight(scanner.Text(), "\r")
+		for strings.HasSuffix(line, "\\") && scanner.Scan() {
+			lineNumber++
+			line = strings.TrimSuffix(line, "\\") + " " + strings.TrimSpace(scanner.Text())
+		}
+
+		trimmed := strings.TrimSpace(line)
+		keyword, _, _ := strings.Cut(trimmed, " ")
+		switch {
+		case inDefine:
+			inDefine = keyword != "endef"
+			continue
+		case keyword == "define":
+			inDefine = true
+			continue
+		case strings.HasPrefix(line, "\t"), trimmed == "":
+			comment = ""
+			continue
+		case strings.HasPrefix(trimmed, "#"):
+			comment = strings.TrimSpace(strings.TrimLeft(trimmed, "#"))
+			continue
+		}
+
+		description := comment
+		comment = ""
+		if before, after, found := strings.Cut(line, "##"); found {
+			line, description = before, strings.TrimSpace(after)
+		} else if before, _, found := strings.Cut(line, "#"); found {
+			line = before
+		}
+
+		switch keyword {
+		case "ifeq", "ifneq", "ifdef", "ifndef", "else", "endif", "include", "-include", "sinclude",
+			"export", "unexport", "override", "vpath", "undefine", "private":
+			continue
+		}
+
+		colon := strings.Index(line, ":")
+		equals := strings.Index(line, "=")
+		if colon < 0 || (equals >= 0 && equals < colon) || strings.HasPrefix(line[colon:], ":=") || strings.HasPrefix(line[colon:], "::=") {
+			// A variable assignment, or a line this parser does not know.
+			continue
+		}
+
+		targets := strings.Fields(line[:colon])
+		rest := strings.TrimLeft(line[colon+1:], ":")
+		if recipe := strings.Index(rest, ";"); recipe >= 0 {
+			rest = rest[:recipe]
+		}
+		if strings.Contains(rest, "=") {
+			// A target-specific variable.
+			continue
+		}
+		prerequisites := strings.Fields(strings.ReplaceAll(rest, "|", " "))
+
+		for _, target := range targets {
+			if target == ".PHONY" {
+				for _, name := range prerequisites {
+					phony[name] = true
+				}
+				continue
+			}
+			rules = append(rules, makeRule{
+				target:        target,
+				prerequisites: prerequisites,
+				description:   description,
+				line:          start,
+			})
+		}
+	}
+	return rules, phony, scanner.Err()
+}
+
+// loadCargoAliases reads the [alias] table of a .cargo/config.toml. Each
+// alias runs as "cargo <alias>".
+func loadCargoAliases(cfg *Config, data []byte) error {
+	var cargo struct {
+		Alias map[string]any `toml:"alias"`
+	}
+	if err := toml.Unmarshal(data, &cargo); err != nil {
+		return err
+	}
+
+	for _, name := range sortedKeys(cargo.Alias) {
+		var expansion string
+		switch value := cargo.Alias[name].(type) {
+		case string:
+			expansion = value
+		case []any:
+			words := make([]string, len(value))
+			for i, word := range value {
+				words[i] = fmt.Sprint(word)
+			}
+			expansion = strings.Join(words, " ")
+		default:
+			return fmt.Errorf("alias '%s' must be a string or a list of strings", name)
+		}
+
+		taskName := externalTaskName(name)
+		cfg.Tasks[taskName] = models.Task{
+			Name:        taskName,
+			Command:     "cargo " + name,
+			Description: "cargo " + expansion,
+		}
+	}
+	return nil
+}
+
+func sortedKeys[T any](values map[string]T) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
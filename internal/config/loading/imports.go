@@ -3,50 +3,326 @@ package loading
 import (
 	"fmt"
 	"path/filepath"
-)
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
 
-func processImports(cfg *Config, baseDir string) error {
-	visited := make(map[string]bool)
-	return processImportsRecursive(cfg, baseDir, visited)
-}
+	"github.com/azuyamat/pace/internal/config/types"
+	"github.com/azuyamat/pace/internal/logger"
+	"github.com/azuyamat/pace/internal/models"
+)
 
-func processImportsRecursive(cfg *Config, baseDir string, visited map[string]bool) error {
-	for _, importPath := range cfg.Imports {
-		fullPath := filepath.Join(baseDir, importPath)
-		absPath, err := filepath.Abs(fullPath)
+// processImports loads the files imported by the config at path and merges
+// their definitions into cfg. stack holds the locations of path and the
+// files importing it, to detect circular imports.
+func processImports(cfg *Config, path string, stack []string) error {
+	for _, imp := range cfg.Imports {
+		fullPath, err := resolveImport(path, imp.Path)
 		if err != nil {
-			return fmt.Errorf("failed to resolve import path %q: %v", fullPath, err)
+			return fmt.Errorf("failed to resolve import path %q: %v", imp.Path, err)
+		}
+		if imp.Kind != types.ImportConfig {
+			importedCfg, err := loadExternal(imp.Kind, fullPath)
+			if err != nil {
+				return err
+			}
+			if err := mergeImport(cfg, importedCfg, imp, fullPath, path); err != nil {
+				return err
+			}
+			continue
 		}
 
-		if visited[absPath] {
-			return fmt.Errorf("circular import detected: %q", absPath)
+		location := importLocation(fullPath)
+		if !isGitImport(fullPath) {
+			if location, err = filepath.Abs(fullPath); err != nil {
+				return fmt.Errorf("failed to resolve import path %q: %v", fullPath, err)
+			}
 		}
 
-		visited[absPath] = true
+		if slices.Contains(stack, location) {
+			return fmt.Errorf("circular import detected: %q imports %q", path, fullPath)
+		}
 
-		importedCfg, err := ParseFile(fullPath)
+		importedCfg, err := parseFile(fullPath, loadOptions{importStack: stack})
 		if err != nil {
 			return err
 		}
-
-		if err := processImportsRecursive(importedCfg, filepath.Dir(fullPath), visited); err != nil {
+		if err := mergeImport(cfg, importedCfg, imp, fullPath, path); err != nil {
 			return err
 		}
+	}
+
+	return nil
+}
 
-		importField(importedCfg.Tasks, cfg.Tasks)
-		importField(importedCfg.Hooks, cfg.Hooks)
-		importField(importedCfg.Constants, cfg.Constants)
-		importField(importedCfg.Globals, cfg.Globals)
-		importField(importedCfg.Aliases, cfg.Aliases)
+// mergeImport merges the definitions of the file at fullPath, imported by
+// imp in the config at path, into cfg.
+func mergeImport(cfg, importedCfg *Config, imp types.Import, fullPath, path string) error {
+	namespaced := imp.Alias != ""
+	if namespaced {
+		namespaceConfig(importedCfg, imp.Alias)
 	}
 
+	// Definitions with a source location report it; the others report
+	// the imported file.
+	from := fmt.Sprintf("%s (imported at %s:%d)", fullPath, path, imp.Line)
+	locateTask := func(name string, imported bool) string {
+		if imported {
+			return importedCfg.Tasks[name].Source.String()
+		}
+		return cfg.Tasks[name].Source.String()
+	}
+	locateTemplate := func(name string, imported bool) string {
+		if imported {
+			return importedCfg.Templates[name].Source.String()
+		}
+		return cfg.Templates[name].Source.String()
+	}
+	locateHook := func(name string, imported bool) string {
+		if imported {
+			return importedCfg.Hooks[name].Source.String()
+		}
+		return cfg.Hooks[name].Source.String()
+	}
+	locateVar := func(name string, imported bool) string {
+		sources, location := cfg.VarSources, "an existing definition"
+		if imported {
+			sources, location = importedCfg.VarSources, from
+		}
+		if source, exists := sources[name]; exists && source.File != "" {
+			return source.String()
+		}
+		return location
+	}
+
+	errs := make([]error, 0)
+	errs = append(errs, importDefinitions("task", importedCfg.Tasks, cfg.Tasks, from, locateTask, namespaced)...)
+	errs = append(errs, importDefinitions("template", importedCfg.Templates, cfg.Templates, from, locateTemplate, namespaced)...)
+	errs = append(errs, importDefinitions("hook", importedCfg.Hooks, cfg.Hooks, from, locateHook, namespaced)...)
+	errs = append(errs, importDefinitions("variable", importedCfg.Constants, cfg.Constants, from, locateVar, namespaced)...)
+	errs = append(errs, importDefinitions("variable", importedCfg.DynamicVars, cfg.DynamicVars, from, locateVar, namespaced)...)
+	errs = append(errs, importDefinitions("alias", importedCfg.Aliases, cfg.Aliases, from, nil, namespaced)...)
+	errs = append(errs, importDefinitions("group", importedCfg.Groups, cfg.Groups, from, nil, namespaced)...)
+	errs = append(errs, importDefinitions("global", importedCfg.Globals, cfg.Globals, from, nil, false)...)
+	if len(errs) > 0 {
+		return importErrors(errs)
+	}
+	cfg.EnvFiles = importEnvFiles(importedCfg.EnvFiles, cfg.EnvFiles)
+	mergeVarUsage(cfg, importedCfg, func(name string) string { return name })
 	return nil
 }
 
-func importField[T any](src, dest map[string]T) {
-	for name, value := range src {
-		if _, exists := dest[name]; !exists {
+// mergeVarUsage keeps track of the variables of an imported file or
+// workspace member, under the names given by qualify.
+func mergeVarUsage(cfg, src *Config, qualify func(string) string) {
+	for name, source := range src.VarSources {
+		if _, exists := cfg.VarSources[qualify(name)]; !exists {
+			cfg.VarSources[qualify(name)] = source
+		}
+	}
+	for name := range src.UsedVars {
+		cfg.UsedVars[qualify(name)] = true
+	}
+}
+
+// importEnvFiles places imported env files before the importing file's own,
+// so that files listed locally take precedence when layered.
+func importEnvFiles(imported, local []string) []string {
+	result := make([]string, 0, len(imported)+len(local))
+	for _, path := range imported {
+		if !slices.Contains(local, path) && !slices.Contains(result, path) {
+			result = append(result, path)
+		}
+	}
+	return append(result, local...)
+}
+
+// importDefinitions copies the definitions in src that dest does not have.
+// A name defined differently in both keeps the definition in dest; this is
+// reported as a warning, or returned as an error when strict is set, naming
+// both locations when locate can tell them from the name and whether the
+// definition is the imported one.
+func importDefinitions[T any](kind string, src, dest map[string]T, from string, locate func(name string, imported bool) string, strict bool) []error {
+	names := make([]string, 0, len(src))
+	for name := range src {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	errs := make([]error, 0)
+	for _, name := range names {
+		value := src[name]
+		existing, exists := dest[name]
+		if !exists {
 			dest[name] = value
+			continue
+		}
+		if reflect.DeepEqual(existing, value) {
+			// The same file imported along two paths.
+			continue
+		}
+
+		imported, kept := from, "an existing definition"
+		if locate != nil {
+			imported, kept = locate(name, true), locate(name, false)
 		}
+		if strict {
+			errs = append(errs, fmt.Errorf("%s '%s' from %s conflicts with %s", kind, name, imported, kept))
+		} else {
+			logger.Warning("%s '%s' from %s is ignored, it is already defined by %s", kind, name, imported, kept)
+		}
+	}
+	return errs
+}
+
+func importErrors(errs []error) error {
+	messages := make([]string, len(errs))
+	for i, err := range errs {
+		messages[i] = "  - " + err.Error()
+	}
+	return fmt.Errorf("import failed:\n%s", strings.Join(messages, "\n"))
+}
+
+var variableReference = regexp.MustCompile(`\$\{([^}]+)\}`)
+
+// namespaceConfig renames the definitions of a config imported with an
+// alias to "<alias>.<name>", and the references between them. Names
+// containing a ':' refer to workspace members and are kept as is.
+func namespaceConfig(cfg *Config, alias string) {
+	qualify := func(name string) string {
+		if strings.Contains(name, ":") {
+			return name
+		}
+		return alias + "." + name
 	}
+	qualifyAll := func(names []string) []string {
+		result := make([]string, len(names))
+		for i, name := range names {
+			result[i] = qualify(name)
+		}
+		return result
+	}
+
+	// Constants are already substituted, but dynamic variables are
+	// evaluated by name when a task runs, so references to them follow
+	// the rename.
+	renameVars := func(s string) string {
+		return variableReference.ReplaceAllStringFunc(s, func(match string) string {
+			name := match[2 : len(match)-1]
+			if _, exists := cfg.DynamicVars[name]; exists {
+				return "${" + qualify(name) + "}"
+			}
+			return match
+		})
+	}
+	renameVarsAll := func(values []string) []string {
+		if values == nil {
+			return nil
+		}
+		result := make([]string, len(values))
+		for i, value := range values {
+			result[i] = renameVars(value)
+		}
+		return result
+	}
+	renameVarsMap := func(values map[string]string) map[string]string {
+		if values == nil {
+			return nil
+		}
+		result := make(map[string]string, len(values))
+		for key, value := range values {
+			result[key] = renameVars(value)
+		}
+		return result
+	}
+
+	renameTask := func(task models.Task) models.Task {
+		task.Name = qualify(task.Name)
+		if task.Alias != "" {
+			task.Alias = qualify(task.Alias)
+		}
+		if _, exists := cfg.Templates[task.Extends]; exists {
+			// Other templates belong to the files importing this one.
+			task.Extends = qualify(task.Extends)
+		}
+		task.DependsOn = qualifyAll(task.DependsOn)
+		task.Requires = qualifyAll(task.Requires)
+		task.Triggers = qualifyAll(task.Triggers)
+		task.OnSuccess = qualifyAll(task.OnSuccess)
+		task.OnFailure = qualifyAll(task.OnFailure)
+		task.Command = renameVars(task.Command)
+		task.WorkingDir = renameVars(task.WorkingDir)
+		task.When = renameVars(task.When)
+		task.Inputs = renameVarsAll(task.Inputs)
+		task.Outputs = renameVarsAll(task.Outputs)
+		task.WatchInputs = renameVarsAll(task.WatchInputs)
+		task.EnvFiles = renameVarsAll(task.EnvFiles)
+		task.Env = renameVarsMap(task.Env)
+		if task.Ready != nil {
+			ready := *task.Ready
+			ready.HTTP = renameVars(ready.HTTP)
+			ready.Command = renameVars(ready.Command)
+			task.Ready = &ready
+		}
+		return task
+	}
+
+	tasks := make(map[string]models.Task, len(cfg.Tasks))
+	for _, task := range cfg.Tasks {
+		task = renameTask(task)
+		tasks[task.Name] = task
+	}
+	templates := make(map[string]models.Task, len(cfg.Templates))
+	for _, template := range cfg.Templates {
+		template = renameTask(template)
+		templates[template.Name] = template
+	}
+	hooks := make(map[string]models.Hook, len(cfg.Hooks))
+	for _, hook := range cfg.Hooks {
+		hook.Name = qualify(hook.Name)
+		hook.Command = renameVars(hook.Command)
+		hook.WorkingDir = renameVars(hook.WorkingDir)
+		hook.EnvFiles = renameVarsAll(hook.EnvFiles)
+		hook.Env = renameVarsMap(hook.Env)
+		hooks[hook.Name] = hook
+	}
+	varSources := make(map[string]models.Source, len(cfg.VarSources))
+	for name, source := range cfg.VarSources {
+		varSources[qualify(name)] = source
+	}
+	usedVars := make(map[string]bool, len(cfg.UsedVars))
+	for name := range cfg.UsedVars {
+		usedVars[qualify(name)] = true
+	}
+	constants := make(map[string]string, len(cfg.Constants))
+	for name, value := range cfg.Constants {
+		constants[qualify(name)] = value
+	}
+	dynamicVars := make(map[string]types.DynamicVar, len(cfg.DynamicVars))
+	for name, dynamicVar := range cfg.DynamicVars {
+		dynamicVar.Name = qualify(name)
+		dynamicVar.Command = renameVars(dynamicVar.Command)
+		dynamicVars[dynamicVar.Name] = dynamicVar
+	}
+	aliases := make(map[string]string, len(cfg.Aliases))
+	for alias, target := range cfg.Aliases {
+		aliases[qualify(alias)] = qualify(target)
+	}
+	groups := make(map[string][]string, len(cfg.Groups))
+	for group, members := range cfg.Groups {
+		groups[qualify(group)] = qualifyAll(members)
+	}
+
+	cfg.Tasks = tasks
+	cfg.Templates = templates
+	cfg.Hooks = hooks
+	cfg.Constants = constants
+	cfg.DynamicVars = dynamicVars
+	cfg.Aliases = aliases
+	cfg.Groups = groups
+	cfg.VarSources = varSources
+	cfg.UsedVars = usedVars
+	cfg.DefaultTask = ""
 }
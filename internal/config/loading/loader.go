@@ -1,61 +1,357 @@
 package loading
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 
+	"github.com/azuyamat/pace/internal/config/dotenv"
 	"github.com/azuyamat/pace/internal/config/parsing"
 	"github.com/azuyamat/pace/internal/config/processing"
 	"github.com/azuyamat/pace/internal/config/types"
+	"github.com/azuyamat/pace/internal/models"
 )
 
 type Config = types.Config
 
-var ConfigFile = "config.pace"
+// ConfigFileNames are the file names FindConfigFile looks for in each
+// directory, in order of preference.
+var ConfigFileNames = []string{"config.pace", ".pace", "pace.pace", "pace.yaml", "pace.yml", "pace.toml", "pace.json"}
+
+// ConfigFile is the config file to load instead of searching for one. It is
+// set by the --file flag.
+var ConfigFile = ""
+
+// vcsDirs mark the root of a repository, where the search for a config file
+// stops.
+var vcsDirs = []string{".git", ".hg", ".svn"}
 
 func NewDefaultConfig() *Config {
 	return types.NewConfig()
 }
 
+// GetConfig loads the config file and changes into its directory, so that
+// the paths it contains, the commands it runs and the cache are relative to
+// it rather than to where pace was started. That directory is kept as the
+// config's InvocationDir. A config file found in a workspace member loads
+// the whole workspace, with the member as the config's Member.
 func GetConfig() (*Config, error) {
-	return ParseFile(ConfigFile)
+	path, err := FindConfigFile()
+	if err != nil {
+		return nil, err
+	}
+	member := ""
+	if ConfigFile == "" {
+		if root, name, exists := findWorkspaceRoot(path); exists {
+			path, member = root, name
+		}
+	}
+	invocationDir, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.Chdir(dir); err != nil {
+			return nil, fmt.Errorf("failed to change to the config directory: %v", err)
+		}
+	}
+	cfg, err := ParseFile(filepath.Base(path))
+	if err != nil {
+		return nil, err
+	}
+	cfg.InvocationDir = invocationDir
+	cfg.Member = member
+	return cfg, nil
+}
+
+// FindConfigFile returns ConfigFile when set. Otherwise it returns the
+// config file in the current directory or the nearest parent that has one,
+// without looking above the root of the enclosing repository.
+func FindConfigFile() (string, error) {
+	if ConfigFile != "" {
+		if _, err := os.Stat(ConfigFile); err != nil {
+			return "", err
+		}
+		return ConfigFile, nil
+	}
+
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	for {
+		if path, exists := configFileIn(dir); exists {
+			return path, nil
+		}
+		if isRepositoryRoot(dir) {
+			break
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+
+	return "", fmt.Errorf("no %s found in this directory or its parents, run 'pace init' to create one", strings.Join(ConfigFileNames, ", "))
+}
+
+// configFileIn returns the config file in dir, if there is one.
+func configFileIn(dir string) (string, bool) {
+	for _, name := range ConfigFileNames {
+		path := filepath.Join(dir, name)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path, true
+		}
+	}
+	return "", false
+}
+
+func isRepositoryRoot(dir string) bool {
+	for _, name := range vcsDirs {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			return true
+		}
+	}
+	return false
 }
 
 func ParseFile(path string) (*Config, error) {
-	data, err := os.ReadFile(path)
+	return parseFile(path, loadOptions{})
+}
+
+// loadOptions describe how a config file is loaded on behalf of another.
+type loadOptions struct {
+	// member is set for workspace members. Their tasks run in the member
+	// directory, and validation is left to the workspace root once every
+	// member has been merged.
+	member bool
+	// importStack holds the absolute paths, or git imports, of the files
+	// importing this one.
+	importStack []string
+}
+
+// parseFile loads a config file, or a file fetched by a git import. Relative
+// paths in a local file are rebased onto its directory, so that they stay
+// correct when it is imported from elsewhere.
+func parseFile(path string, opts loadOptions) (*Config, error) {
+	data, err := readConfigFile(path)
 	if err != nil {
 		return nil, err
 	}
 
-	cfg, err := parsing.Parse(string(data))
+	cfg, err := parseConfig(path, data)
 	if err != nil {
 		return nil, err
 	}
+	setSourceFile(cfg, path)
 
-	if err := processImports(cfg, filepath.Dir(path)); err != nil {
+	location := importLocation(path)
+	if !isGitImport(path) {
+		rebaseConfig(cfg, filepath.Dir(path))
+		if location, err = filepath.Abs(path); err != nil {
+			return nil, err
+		}
+	}
+	stack := append(slices.Clone(opts.importStack), location)
+	if err := processImports(cfg, path, stack); err != nil {
 		return nil, err
 	}
 
+	// Rebased paths are relative to the directory pace runs in, the one of
+	// the main config file.
+	if cfg.Dir, err = os.Getwd(); err != nil {
+		return nil, err
+	}
+	envFiles := make([]string, len(cfg.EnvFiles))
+	for i, file := range cfg.EnvFiles {
+		envFiles[i] = rebasePath(cfg.Dir, file)
+	}
+	dotEnv, err := dotenv.LoadFiles(envFiles, os.LookupEnv)
+	if err != nil {
+		return nil, err
+	}
+	cfg.DotEnv = dotEnv
+
+	// Imported files may extend templates of the files importing them, so
+	// templates are resolved, and the result validated, once every import
+	// has been merged.
+	if len(opts.importStack) == 0 {
+		templateResolver := processing.NewTemplateResolver(cfg)
+		if err := templateResolver.Resolve(); err != nil {
+			return nil, err
+		}
+	}
+
 	resolver := processing.NewResolver(cfg)
+	for name, dynamicVar := range cfg.DynamicVars {
+		dynamicVar.Command = resolver.ResolveString(dynamicVar.Command)
+		cfg.DynamicVars[name] = dynamicVar
+	}
 	for name, task := range cfg.Tasks {
-		task.Command = resolver.ResolveString(task.Command)
+		task.Command = resolver.ResolveCommand(task.Command, task.Args)
 		task.Inputs = resolver.ResolveStringSlice(task.Inputs)
 		task.Outputs = resolver.ResolveStringSlice(task.Outputs)
+		task.WatchInputs = resolver.ResolveStringSlice(task.WatchInputs)
 		task.WorkingDir = resolver.ResolveString(task.WorkingDir)
 		task.Env = resolver.ResolveStringMap(task.Env)
+		task.EnvFiles = resolver.ResolveStringSlice(task.EnvFiles)
+		if task.Ready != nil {
+			ready := *task.Ready
+			ready.HTTP = resolver.ResolveString(ready.HTTP)
+			ready.Log = resolver.ResolveString(ready.Log)
+			ready.Command = resolver.ResolveString(ready.Command)
+			task.Ready = &ready
+		}
 		cfg.Tasks[name] = task
 	}
 	for name, hook := range cfg.Hooks {
 		hook.Command = resolver.ResolveString(hook.Command)
 		hook.WorkingDir = resolver.ResolveString(hook.WorkingDir)
 		hook.Env = resolver.ResolveStringMap(hook.Env)
+		hook.EnvFiles = resolver.ResolveStringSlice(hook.EnvFiles)
 		cfg.Hooks[name] = hook
 	}
 
-	validator := processing.NewValidator(cfg)
-	if err := validator.Validate(); err != nil {
-		return nil, err
+	if opts.member {
+		if cfg.Workspace != nil {
+			return nil, fmt.Errorf("%s: nested workspaces are not supported", path)
+		}
+		setWorkingDir(cfg, filepath.Dir(path))
+		return cfg, nil
+	}
+
+	if cfg.Workspace != nil {
+		if err := loadWorkspace(cfg, filepath.Dir(path)); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(opts.importStack) == 0 {
+		validator := processing.NewValidator(cfg)
+		if err := validator.Validate(); err != nil {
+			return nil, err
+		}
 	}
 
 	return cfg, nil
 }
+
+// ParseUnprocessed parses a config file as written, without loading its
+// imports or resolving templates and variables.
+func ParseUnprocessed(path string) (*Config, error) {
+	data, err := readConfigFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parseConfig(path, data)
+}
+
+// parseConfig parses a config file in the format given by its extension.
+// Errors in YAML, TOML and JSON files name the file, as they have no
+// source excerpt.
+func parseConfig(path string, data []byte) (*Config, error) {
+	format := configFormat(path)
+	cfg, err := parsing.ParseFormat(data, format)
+	if err != nil && format != types.FormatPace {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return cfg, err
+}
+
+// configFormat returns the format of a config file, or of the file a git
+// import points to.
+func configFormat(path string) types.Format {
+	if isGitImport(path) {
+		if imp, err := parseGitImport(path); err == nil {
+			return types.FormatOf(imp.Path)
+		}
+	}
+	return types.FormatOf(path)
+}
+
+func readConfigFile(path string) ([]byte, error) {
+	if isGitImport(path) {
+		return fetchGitImport(path)
+	}
+	return os.ReadFile(path)
+}
+
+// setSourceFile records path as the file defining every task, template and
+// hook parsed from it, before imported definitions are merged in.
+func setSourceFile(cfg *Config, path string) {
+	for name, task := range cfg.Tasks {
+		task.Source.File = path
+		cfg.Tasks[name] = task
+	}
+	for name, template := range cfg.Templates {
+		template.Source.File = path
+		cfg.Templates[name] = template
+	}
+	for name, hook := range cfg.Hooks {
+		hook.Source.File = path
+		cfg.Hooks[name] = hook
+	}
+	for name, source := range cfg.VarSources {
+		source.File = path
+		cfg.VarSources[name] = source
+	}
+	for name, dynamicVar := range cfg.DynamicVars {
+		dynamicVar.Source.File = path
+		cfg.DynamicVars[name] = dynamicVar
+	}
+}
+
+// rebaseConfig makes the relative paths of a config file relative to the
+// directory pace runs in, given the file's directory.
+func rebaseConfig(cfg *Config, dir string) {
+	if dir == "." {
+		return
+	}
+	for name, task := range cfg.Tasks {
+		cfg.Tasks[name] = rebaseTask(task, dir)
+	}
+	for name, template := range cfg.Templates {
+		cfg.Templates[name] = rebaseTask(template, dir)
+	}
+	for name, hook := range cfg.Hooks {
+		if hook.WorkingDir != "" {
+			hook.WorkingDir = rebasePath(dir, hook.WorkingDir)
+		}
+		hook.EnvFiles = rebasePaths(dir, hook.EnvFiles)
+		cfg.Hooks[name] = hook
+	}
+	cfg.EnvFiles = rebasePaths(dir, cfg.EnvFiles)
+}
+
+func rebaseTask(task models.Task, dir string) models.Task {
+	if task.WorkingDir != "" {
+		task.WorkingDir = rebasePath(dir, task.WorkingDir)
+	}
+	task.Inputs = rebasePaths(dir, task.Inputs)
+	task.Outputs = rebasePaths(dir, task.Outputs)
+	task.WatchInputs = rebasePaths(dir, task.WatchInputs)
+	task.EnvFiles = rebasePaths(dir, task.EnvFiles)
+	return task
+}
+
+func rebasePath(dir, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
+
+func rebasePaths(dir string, paths []string) []string {
+	if paths == nil {
+		return nil
+	}
+	result := make([]string, len(paths))
+	for i, path := range paths {
+		result[i] = rebasePath(dir, path)
+	}
+	return result
+}
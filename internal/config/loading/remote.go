@@ -0,0 +1,386 @@
+package loading
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// LockFile pins the content of imports fetched from git. It is kept next to
+// the config file.
+const LockFile = "pace.lock"
+
+// gitImportPrefix starts imports of a file in a git repository:
+//
+//	git+file:///path/to/repo.git//tasks/go.pace@v1.2.0
+//
+// The repository URL and the path of the file inside it are separated by
+// "//", and the optional ref after "@" defaults to HEAD.
+const gitImportPrefix = "git+"
+
+type gitImport struct {
+	URL  string
+	Path string
+	Ref  string
+}
+
+func isGitImport(spec string) bool {
+	return strings.HasPrefix(spec, gitImportPrefix)
+}
+
+func parseGitImport(spec string) (gitImport, error) {
+	rest := strings.TrimPrefix(spec, gitImportPrefix)
+	schemeEnd := strings.Index(rest, "://")
+	if schemeEnd < 0 {
+		return gitImport{}, fmt.Errorf("invalid git import %q: expected a URL such as git+file:///path/to/repo.git//tasks.pace@v1.0.0", spec)
+	}
+	separator := strings.Index(rest[schemeEnd+3:], "//")
+	if separator < 0 {
+		return gitImport{}, fmt.Errorf("invalid git import %q: separate the repository from the file with '//', e.g. repo.git//tasks.pace", spec)
+	}
+	separator += schemeEnd + 3
+
+	imp := gitImport{URL: rest[:separator], Path: rest[separator+2:], Ref: "HEAD"}
+	if at := strings.LastIndex(imp.Path, "@"); at >= 0 {
+		imp.Path, imp.Ref = imp.Path[:at], imp.Path[at+1:]
+	}
+	if imp.Path == "" || imp.Ref == "" {
+		return gitImport{}, fmt.Errorf("invalid git import %q: missing file path or ref", spec)
+	}
+	return imp, nil
+}
+
+func (g gitImport) String() string {
+	return gitImportPrefix + g.URL + "//" + g.Path + "@" + g.Ref
+}
+
+// resolveImport returns the location of an import made by the file at
+// parent: a git import, or a path relative to the directory pace runs in.
+// Relative imports made by a file fetched from git refer to the same
+// repository, at the commit the file was fetched from.
+func resolveImport(parent, spec string) (string, error) {
+	switch {
+	case isGitImport(spec):
+		return spec, nil
+	case strings.HasPrefix(spec, "~/"):
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(home, spec[2:]), nil
+	case filepath.IsAbs(spec):
+		return spec, nil
+	case isGitImport(parent):
+		imp, err := parseGitImport(parent)
+		if err != nil {
+			return "", err
+		}
+		imp.Path = path.Join(path.Dir(imp.Path), filepath.ToSlash(spec))
+		if locked, exists := imports.lock.Imports[parent]; exists {
+			imp.Ref = locked.Commit
+		}
+		return imp.String(), nil
+	default:
+		return filepath.Join(filepath.Dir(parent), spec), nil
+	}
+}
+
+// importLocation identifies a git import by the commit it is locked at, so
+// that the same file reached through different refs is recognized.
+func importLocation(spec string) string {
+	imp, err := parseGitImport(spec)
+	if err != nil || imports.lock == nil {
+		return spec
+	}
+	if locked, exists := imports.lock.Imports[spec]; exists {
+		imp.Ref = locked.Commit
+	}
+	return imp.String()
+}
+
+// lockedImport is the pinned state of a git import.
+type lockedImport struct {
+	Commit string `json:"commit"`
+	SHA256 string `json:"sha256"`
+}
+
+type lockFile struct {
+	Imports map[string]lockedImport `json:"imports"`
+}
+
+// imports holds the lock file while a config is loaded. When update is set,
+// refs are resolved again instead of using the locked commits.
+var imports struct {
+	lock   *lockFile
+	update bool
+}
+
+func loadLock() (*lockFile, error) {
+	if imports.lock != nil {
+		return imports.lock, nil
+	}
+	lock, err := readLock()
+	if err != nil {
+		return nil, err
+	}
+	imports.lock = lock
+	return lock, nil
+}
+
+func readLock() (*lockFile, error) {
+	lock := &lockFile{Imports: make(map[string]lockedImport)}
+	data, err := os.ReadFile(LockFile)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, lock); err != nil {
+			return nil, fmt.Errorf("failed to read %s: %v", LockFile, err)
+		}
+		if lock.Imports == nil {
+			lock.Imports = make(map[string]lockedImport)
+		}
+	}
+	return lock, nil
+}
+
+func saveLock(lock *lockFile) error {
+	data, err := json.MarshalIndent(lock, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(LockFile, append(data, '\n'), 0644)
+}
+
+// fetchGitImport returns the content of a git import. The commit and
+// content hash are taken from the lock file and the content from the local
+// cache when possible, so that loading works offline. Imports missing from
+// the lock file are fetched and added to it.
+func fetchGitImport(spec string) ([]byte, error) {
+	imp, err := parseGitImport(spec)
+	if err != nil {
+		return nil, err
+	}
+	lock, err := loadLock()
+	if err != nil {
+		return nil, err
+	}
+
+	locked, exists := lock.Imports[spec]
+	if exists && !imports.update {
+		if data, err := readCachedImport(locked.SHA256); err == nil {
+			return data, nil
+		}
+		data, err := gitShow(imp, locked.Commit)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch %s: %v", spec, err)
+		}
+		if hash := contentHash(data); hash != locked.SHA256 {
+			return nil, fmt.Errorf("content of %s at %s does not match %s (expected sha256 %s, got %s)", spec, locked.Commit, LockFile, locked.SHA256, hash)
+		}
+		return data, writeCachedImport(locked.SHA256, data)
+	}
+
+	commit, err := gitResolve(imp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %v", spec, err)
+	}
+	data, err := gitShow(imp, commit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %v", spec, err)
+	}
+	locked = lockedImport{Commit: commit, SHA256: contentHash(data)}
+	if err := writeCachedImport(locked.SHA256, data); err != nil {
+		return nil, err
+	}
+
+	lock.Imports[spec] = locked
+	if imports.update {
+		// UpdateImports writes the lock file once every import is loaded.
+		return data, nil
+	}
+	return data, saveLock(lock)
+}
+
+// PaceHome is the per-user directory holding shared task libraries under
+// lib and fetched imports under cache. PACE_HOME overrides it.
+func PaceHome() (string, error) {
+	if dir := os.Getenv("PACE_HOME"); dir != "" {
+		return dir, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".pace"), nil
+}
+
+func cachePath(parts ...string) (string, error) {
+	home, err := PaceHome()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(append([]string{home, "cache"}, parts...)...), nil
+}
+
+func readCachedImport(hash string) ([]byte, error) {
+	path, err := cachePath("imports", hash+".pace")
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if contentHash(data) != hash {
+		return nil, fmt.Errorf("cached import %s is corrupt", path)
+	}
+	return data, nil
+}
+
+func writeCachedImport(hash string, data []byte) error {
+	path, err := cachePath("imports", hash+".pace")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+func contentHash(data []byte) string {
+	return fmt.Sprintf("%x", sha256.Sum256(data))
+}
+
+// gitMirror returns the bare clone of the repository in the cache, cloning
+// it first if needed. fetch updates an existing clone.
+func gitMirror(imp gitImport, fetch bool) (string, error) {
+	dir, err := cachePath("git", contentHash([]byte(imp.URL))[:16])
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := os.Stat(dir); errors.Is(err, os.ErrNotExist) {
+		if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+			return "", err
+		}
+		if _, err := git("", "clone", "--bare", "--quiet", imp.URL, dir); err != nil {
+			return "", err
+		}
+		return dir, nil
+	}
+
+	if fetch {
+		if _, err := git(dir, "fetch", "--quiet", "--tags", "--force", "origin", "+refs/heads/*:refs/heads/*"); err != nil {
+			return "", err
+		}
+	}
+	return dir, nil
+}
+
+// gitResolve fetches the repository and returns the commit the import's
+// ref points to.
+func gitResolve(imp gitImport) (string, error) {
+	dir, err := gitMirror(imp, true)
+	if err != nil {
+		return "", err
+	}
+	commit, err := git(dir, "rev-parse", "--verify", "--quiet", imp.Ref+"^{commit}")
+	if err != nil {
+		return "", fmt.Errorf("ref %q not found in %s", imp.Ref, imp.URL)
+	}
+	return commit, nil
+}
+
+// gitShow returns the content of the import's file at commit, fetching the
+// repository if the local clone does not have the commit.
+func gitShow(imp gitImport, commit string) ([]byte, error) {
+	dir, err := gitMirror(imp, false)
+	if err != nil {
+		return nil, err
+	}
+	content, err := gitOutput(dir, "show", commit+":"+imp.Path)
+	if err != nil && !gitHasCommit(dir, commit) {
+		if _, err := gitMirror(imp, true); err != nil {
+			return nil, err
+		}
+		content, err = gitOutput(dir, "show", commit+":"+imp.Path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s at %s from %s: %v", imp.Path, commit, imp.URL, err)
+	}
+	return content, nil
+}
+
+func gitHasCommit(dir, commit string) bool {
+	_, err := git(dir, "cat-file", "-e", commit+"^{commit}")
+	return err == nil
+}
+
+func git(dir string, args ...string) (string, error) {
+	out, err := gitOutput(dir, args...)
+	return strings.TrimSpace(string(out)), err
+}
+
+func gitOutput(dir string, args ...string) ([]byte, error) {
+	command := args[0]
+	if dir != "" {
+		args = append([]string{"-C", dir}, args...)
+	}
+	out, err := exec.Command("git", args...).Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+			return nil, fmt.Errorf("git %s: %s", command, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return nil, fmt.Errorf("git %s: %v", command, err)
+	}
+	return out, nil
+}
+
+// LockedImport describes a git import pinned in the lock file.
+type LockedImport struct {
+	Import string
+	Commit string
+}
+
+// UpdateImports resolves the refs of every git import again, fetching
+// their repositories, and rewrites the lock file with the result. Imports
+// no longer used are dropped from it. It returns the entries before and
+// after the update.
+func UpdateImports() (before, after []LockedImport, err error) {
+	imports.lock = &lockFile{Imports: make(map[string]lockedImport)}
+	imports.update = true
+	defer func() { imports.update = false }()
+
+	if _, err := GetConfig(); err != nil {
+		return nil, nil, err
+	}
+	previous, err := readLock()
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := saveLock(imports.lock); err != nil {
+		return nil, nil, err
+	}
+	return lockedImports(previous), lockedImports(imports.lock), nil
+}
+
+func lockedImports(lock *lockFile) []LockedImport {
+	result := make([]LockedImport, 0, len(lock.Imports))
+	for spec, locked := range lock.Imports {
+		result = append(result, LockedImport{Import: spec, Commit: locked.Commit})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Import < result[j].Import
+	})
+	return result
+}
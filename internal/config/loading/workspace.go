@@ -0,0 +1,167 @@
+package loading
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/azuyamat/pace/internal/logger"
+)
+
+// loadWorkspace loads the config file of every workspace member and merges
+// its tasks, hooks, aliases and groups into cfg as "<member>:<name>".
+func loadWorkspace(cfg *Config, rootDir string) error {
+	members, err := findMembers(rootDir, cfg.Workspace.Patterns)
+	if err != nil {
+		return err
+	}
+	cfg.Workspace.Members = members
+
+	for _, member := range members {
+		dir := filepath.Join(rootDir, filepath.FromSlash(member))
+		path, _ := configFileIn(dir)
+		memberCfg, err := parseFile(path, loadOptions{member: true})
+		if err != nil {
+			return fmt.Errorf("workspace member %q: %v", member, err)
+		}
+		mergeMember(cfg, member, memberCfg)
+	}
+
+	return nil
+}
+
+// findWorkspaceRoot returns the config file of the workspace, in a parent
+// directory within the repository, that the directory of path is a member
+// of, along with the name of the member.
+func findWorkspaceRoot(path string) (string, string, bool) {
+	memberDir, err := filepath.Abs(filepath.Dir(path))
+	if err != nil {
+		return "", "", false
+	}
+	for dir := memberDir; !isRepositoryRoot(dir); {
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+
+		rootPath, exists := configFileIn(dir)
+		if !exists {
+			continue
+		}
+		rootCfg, err := ParseUnprocessed(rootPath)
+		if err != nil || rootCfg.Workspace == nil {
+			continue
+		}
+		rel, err := filepath.Rel(dir, memberDir)
+		if err != nil {
+			continue
+		}
+		for _, pattern := range rootCfg.Workspace.Patterns {
+			if matched, _ := filepath.Match(filepath.FromSlash(pattern), rel); matched {
+				return rootPath, filepath.ToSlash(rel), true
+			}
+		}
+	}
+	return "", "", false
+}
+
+// findMembers returns the directories matched by the workspace patterns that
+// contain one of ConfigFileNames, relative to rootDir and sorted.
+func findMembers(rootDir string, patterns []string) ([]string, error) {
+	members := make([]string, 0)
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(filepath.Join(rootDir, filepath.FromSlash(pattern)))
+		if err != nil {
+			return nil, fmt.Errorf("invalid workspace pattern %q: %v", pattern, err)
+		}
+
+		found := false
+		for _, match := range matches {
+			if _, exists := configFileIn(match); !exists {
+				continue
+			}
+			rel, err := filepath.Rel(rootDir, match)
+			if err != nil {
+				return nil, err
+			}
+			member := filepath.ToSlash(rel)
+			if !slices.Contains(members, member) {
+				members = append(members, member)
+			}
+			found = true
+		}
+		if !found {
+			logger.Warning("Workspace pattern %q matches no directory with a config file", pattern)
+		}
+	}
+	sort.Strings(members)
+	return members, nil
+}
+
+// mergeMember adds the definitions of a member to the workspace root. Names
+// that already contain a ':' refer to another member and are kept as is.
+func mergeMember(cfg *Config, member string, memberCfg *Config) {
+	qualify := func(name string) string {
+		if strings.Contains(name, ":") {
+			return name
+		}
+		return member + ":" + name
+	}
+	qualifyAll := func(names []string) []string {
+		result := make([]string, len(names))
+		for i, name := range names {
+			result[i] = qualify(name)
+		}
+		return result
+	}
+
+	for name, task := range memberCfg.Tasks {
+		task.Name = qualify(name)
+		if task.Alias != "" {
+			task.Alias = qualify(task.Alias)
+		}
+		task.DependsOn = qualifyAll(task.DependsOn)
+		task.Requires = qualifyAll(task.Requires)
+		task.Triggers = qualifyAll(task.Triggers)
+		task.OnSuccess = qualifyAll(task.OnSuccess)
+		task.OnFailure = qualifyAll(task.OnFailure)
+		// The member's env files apply to its tasks only, below the task's own.
+		task.EnvFiles = append(slices.Clone(memberCfg.EnvFiles), task.EnvFiles...)
+		cfg.Tasks[task.Name] = task
+	}
+	for name, hook := range memberCfg.Hooks {
+		hook.Name = qualify(name)
+		hook.EnvFiles = append(slices.Clone(memberCfg.EnvFiles), hook.EnvFiles...)
+		cfg.Hooks[hook.Name] = hook
+	}
+	for alias, target := range memberCfg.Aliases {
+		cfg.Aliases[qualify(alias)] = qualify(target)
+	}
+	for group, members := range memberCfg.Groups {
+		cfg.Groups[qualify(group)] = qualifyAll(members)
+	}
+	// Dynamic variables are evaluated by name at run time, so they share one
+	// namespace across the workspace; the root and earlier members win.
+	importDefinitions("variable", memberCfg.DynamicVars, cfg.DynamicVars, member, nil, false)
+	mergeVarUsage(cfg, memberCfg, qualify)
+}
+
+// setWorkingDir runs the tasks and hooks of a workspace member that do not
+// set a working directory in the member directory.
+func setWorkingDir(cfg *Config, dir string) {
+	for name, task := range cfg.Tasks {
+		if task.WorkingDir == "" {
+			task.WorkingDir = dir
+			cfg.Tasks[name] = task
+		}
+	}
+	for name, hook := range cfg.Hooks {
+		if hook.WorkingDir == "" {
+			hook.WorkingDir = dir
+			cfg.Hooks[name] = hook
+		}
+	}
+}
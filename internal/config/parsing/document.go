@@ -0,0 +1,644 @@
+package parsing
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+
+	"github.com/BurntSushi/toml"
+	"github.com/azuyamat/pace/internal/config/types"
+	"github.com/azuyamat/pace/internal/models"
+	"gopkg.in/yaml.v3"
+)
+
+// ParseFormat parses a config file written in the given format. YAML, TOML
+// and JSON files follow types.Document, and their task and hook properties
+// are read through the same registries as the pace syntax, aliases
+// included.
+func ParseFormat(input []byte, format types.Format) (*types.Config, error) {
+	if format == types.FormatPace {
+		return Parse(string(input))
+	}
+
+	values := make(map[string]any)
+	var err error
+	switch format {
+	case types.FormatYAML:
+		err = yaml.Unmarshal(input, &values)
+	case types.FormatTOML:
+		err = toml.Unmarshal(input, &values)
+	case types.FormatJSON:
+		decoder := json.NewDecoder(bytes.NewReader(input))
+		decoder.UseNumber()
+		err = decoder.Decode(&values)
+	default:
+		return nil, fmt.Errorf("unknown config format '%s'", format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %v", format, err)
+	}
+
+	config := types.NewConfig()
+	if err := parseDocument(config, values); err != nil {
+		return nil, err
+	}
+	if format != types.FormatTOML {
+		setDocumentLines(config, input)
+	}
+	return config, nil
+}
+
+// setDocumentLines records the line of every task, template, hook and
+// variable of a YAML or JSON document, JSON being read as YAML.
+func setDocumentLines(config *types.Config, input []byte) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(input, &root); err != nil || len(root.Content) == 0 {
+		return
+	}
+	lines := func(node *yaml.Node, set func(name string, line int)) {
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			set(node.Content[i].Value, node.Content[i].Line)
+		}
+	}
+
+	document := root.Content[0]
+	for i := 0; i+1 < len(document.Content); i += 2 {
+		section := document.Content[i+1]
+		switch document.Content[i].Value {
+		case "tasks":
+			lines(section, func(name string, line int) {
+				task := config.Tasks[name]
+				task.Source.Line = line
+				config.Tasks[name] = task
+			})
+		case "templates":
+			lines(section, func(name string, line int) {
+				template := config.Templates[name]
+				template.Source.Line = line
+				config.Templates[name] = template
+			})
+		case "hooks":
+			lines(section, func(name string, line int) {
+				hook := config.Hooks[name]
+				hook.Source.Line = line
+				config.Hooks[name] = hook
+			})
+		case "vars":
+			lines(section, func(name string, line int) {
+				config.VarSources[name] = models.Source{Line: line}
+				if dynamicVar, exists := config.DynamicVars[name]; exists {
+					dynamicVar.Source.Line = line
+					config.DynamicVars[name] = dynamicVar
+				}
+			})
+		}
+	}
+}
+
+func parseDocument(config *types.Config, values map[string]any) error {
+	for _, key := range sortedKeys(values) {
+		value := values[key]
+		var err error
+		switch key {
+		case "default":
+			config.DefaultTask, err = documentString(key, value)
+		case "imports":
+			err = parseDocumentImports(config, value)
+		case "env_file":
+			config.EnvFiles, err = documentStrings(key, value)
+		case "vars":
+			err = parseDocumentVars(config, value)
+		case "globals":
+			config.Globals, err = documentStringMap(key, value)
+		case "aliases":
+			var aliases map[string]string
+			if aliases, err = documentStringMap(key, value); err == nil {
+				for alias, target := range aliases {
+					config.Aliases[alias] = target
+				}
+			}
+		case "groups":
+			err = parseDocumentGroups(config, value)
+		case "workspace":
+			err = parseDocumentWorkspace(config, value)
+		case "templates":
+			err = parseDocumentTasks(config, key, value, config.Templates)
+		case "tasks":
+			err = parseDocumentTasks(config, key, value, config.Tasks)
+		case "hooks":
+			err = parseDocumentHooks(config, value)
+		default:
+			err = fmt.Errorf("unknown key '%s', expected one of default, imports, env_file, vars, globals, aliases, groups, workspace, templates, tasks and hooks", key)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func parseDocumentImports(config *types.Config, value any) error {
+	items, ok := documentList(value)
+	if !ok {
+		return fmt.Errorf("'imports' must be a list of paths or {path, as, kind} objects")
+	}
+	for _, item := range items {
+		if path, ok := item.(string); ok {
+			config.Imports = append(config.Imports, types.Import{Path: path})
+			continue
+		}
+		fields, err := documentObject("imports", item, "path", "as", "kind")
+		if err != nil {
+			return err
+		}
+		imp := types.Import{}
+		if imp.Path, err = documentString("imports.path", fields["path"]); err != nil {
+			return err
+		}
+		if alias, exists := fields["as"]; exists {
+			if imp.Alias, err = documentString("imports.as", alias); err != nil {
+				return err
+			}
+		}
+		if value, exists := fields["kind"]; exists {
+			kind, err := documentString("imports.kind", value)
+			if err != nil {
+				return err
+			}
+			imp.Kind = types.ImportKind(kind)
+			if kind == "" || !slices.Contains(types.ImportKinds, imp.Kind) {
+				return fmt.Errorf("import '%s' has unknown kind '%s', expected scripts, make or cargo", imp.Path, kind)
+			}
+		}
+		config.Imports = append(config.Imports, imp)
+	}
+	return nil
+}
+
+// parseDocumentVars reads constants, and dynamic variables written as
+// {sh: "command"}.
+func parseDocumentVars(config *types.Config, value any) error {
+	vars, ok := value.(map[string]any)
+	if !ok {
+		return fmt.Errorf("'vars' must map variable names to values")
+	}
+	for name, value := range vars {
+		config.VarSources[name] = models.Source{}
+		if constant, ok := scalarString(value); ok {
+			config.Constants[name] = constant
+			continue
+		}
+		fields, err := documentObject("vars."+name, value, "sh")
+		if err != nil {
+			return fmt.Errorf("variable '%s' must be a string or {sh: \"command\"}", name)
+		}
+		command, err := documentString("vars."+name+".sh", fields["sh"])
+		if err != nil {
+			return err
+		}
+		config.DynamicVars[name] = types.DynamicVar{Name: name, Command: command}
+	}
+	return nil
+}
+
+func parseDocumentGroups(config *types.Config, value any) error {
+	groups, ok := value.(map[string]any)
+	if !ok {
+		return fmt.Errorf("'groups' must map group names to lists of tasks")
+	}
+	for name, members := range groups {
+		tasks, err := documentStrings("groups."+name, members)
+		if err != nil {
+			return err
+		}
+		config.Groups[name] = tasks
+	}
+	return nil
+}
+
+func parseDocumentWorkspace(config *types.Config, value any) error {
+	fields, err := documentObject("workspace", value, "members")
+	if err != nil {
+		return err
+	}
+	patterns, err := documentStrings("workspace.members", fields["members"])
+	if err != nil {
+		return err
+	}
+	config.Workspace = &types.Workspace{Patterns: patterns}
+	return nil
+}
+
+func parseDocumentTasks(config *types.Config, kind string, value any, dest map[string]models.Task) error {
+	tasks, ok := value.(map[string]any)
+	if !ok {
+		return fmt.Errorf("'%s' must map names to objects", kind)
+	}
+	for _, name := range sortedKeys(tasks) {
+		properties, ok := tasks[name].(map[string]any)
+		if !ok {
+			return fmt.Errorf("%s.%s must be an object of properties", kind, name)
+		}
+		task := models.Task{Name: name, Overrides: make(map[string]models.MergeMode)}
+		if err := parseDocumentTask(&task, properties); err != nil {
+			return fmt.Errorf("%s.%s: %v", kind, name, err)
+		}
+		dest[name] = task
+		if kind == "tasks" && task.Alias != "" {
+			config.Aliases[task.Alias] = task.Name
+		}
+	}
+	return nil
+}
+
+func parseDocumentTask(task *models.Task, properties map[string]any) error {
+	for _, name := range sortedKeys(properties) {
+		value := properties[name]
+		if name == "alias" || name == "extends" {
+			target, err := documentString(name, value)
+			if err != nil {
+				return err
+			}
+			if name == "alias" {
+				task.Alias = target
+			} else {
+				task.Extends = target
+			}
+			continue
+		}
+
+		propDef, exists := taskPropertyRegistry[name]
+		if !exists {
+			return fmt.Errorf("unknown property '%s'", name)
+		}
+
+		var err error
+		mode := models.MergeReplace
+		switch name {
+		case "args":
+			err = parseDocumentArgs(task, value)
+		case "arg":
+			err = parseDocumentArg(task, value)
+		case "watch":
+			err = parseDocumentWatch(task, value)
+		case "ready":
+			err = parseDocumentReady(task, value)
+		default:
+			label := name
+			if propDef.Type == PropStringArray {
+				if fields, isObject := value.(map[string]any); isObject {
+					// {append: [...]} is the document form of "+=".
+					if fields, err = documentObject(name, fields, "append"); err != nil {
+						return err
+					}
+					label = name + ".append"
+					value = fields["append"]
+					mode = models.MergeAppend
+				}
+			}
+			var converted any
+			if converted, err = documentValue(propDef.Type, label, value); err == nil {
+				err = setFieldValue(reflect.ValueOf(task).Elem(), propDef.TaskField, converted)
+			}
+		}
+		if err != nil {
+			return err
+		}
+		if propDef.TaskField != "" {
+			recordOverride(task, propDef.TaskField, mode)
+		}
+	}
+	return nil
+}
+
+func parseDocumentArgs(task *models.Task, value any) error {
+	fields, err := documentObject("args", value, "required", "optional")
+	if err != nil {
+		return err
+	}
+	if task.Args == nil {
+		task.Args = &models.TaskArgs{Required: []string{}, Optional: []string{}}
+	}
+	// Declared arguments add themselves to the same lists.
+	if value, exists := fields["required"]; exists {
+		required, err := documentStrings("args.required", value)
+		if err != nil {
+			return err
+		}
+		task.Args.Required = append(task.Args.Required, required...)
+	}
+	if value, exists := fields["optional"]; exists {
+		optional, err := documentStrings("args.optional", value)
+		if err != nil {
+			return err
+		}
+		task.Args.Optional = append(task.Args.Optional, optional...)
+	}
+	return nil
+}
+
+// parseDocumentArg reads typed argument declarations, a list of
+// {name, type, default, choices} objects.
+func parseDocumentArg(task *models.Task, value any) error {
+	items, ok := documentList(value)
+	if !ok {
+		return fmt.Errorf("'arg' must be a list of {name, type, default, choices} objects")
+	}
+	if task.Args == nil {
+		task.Args = &models.TaskArgs{Required: []string{}, Optional: []string{}}
+	}
+
+	for _, item := range items {
+		fields, err := documentObject("arg", item, "name", "type", "default", "choices")
+		if err != nil {
+			return err
+		}
+		arg := models.TaskArg{Type: models.ArgTypeString}
+		if arg.Name, err = documentString("arg.name", fields["name"]); err != nil {
+			return err
+		}
+		if argType, exists := fields["type"]; exists {
+			name, err := documentString("arg.type", argType)
+			if err != nil {
+				return err
+			}
+			switch models.ArgType(name) {
+			case models.ArgTypeString, models.ArgTypeInt, models.ArgTypeBool:
+				arg.Type = models.ArgType(name)
+			default:
+				return fmt.Errorf("argument '%s' has unknown type '%s', expected string, int or bool", arg.Name, name)
+			}
+		}
+		if value, exists := fields["default"]; exists {
+			if arg.Default, ok = scalarString(value); !ok {
+				return fmt.Errorf("default of argument '%s' must be a string, number or boolean", arg.Name)
+			}
+			arg.HasDefault = true
+		}
+		if choices, exists := fields["choices"]; exists {
+			if arg.Choices, err = documentStrings("arg.choices", choices); err != nil {
+				return err
+			}
+		}
+
+		if _, exists := task.Args.Lookup(arg.Name); exists {
+			return fmt.Errorf("argument '%s' is declared more than once", arg.Name)
+		}
+		task.Args.Declared = append(task.Args.Declared, arg)
+		if arg.HasDefault || arg.Type == models.ArgTypeBool {
+			task.Args.Optional = append(task.Args.Optional, arg.Name)
+		} else {
+			task.Args.Required = append(task.Args.Required, arg.Name)
+		}
+	}
+	return nil
+}
+
+// parseDocumentWatch reads "watch: true", or an object of watch settings
+// where "enabled" stands for "watch true".
+func parseDocumentWatch(task *models.Task, value any) error {
+	if watch, ok := value.(bool); ok {
+		task.Watch = watch
+		recordOverride(task, "Watch", models.MergeReplace)
+		return nil
+	}
+
+	fields, err := documentObject("watch", value, "enabled", "debounce", "mode", "clear", "run_on_start", "interactive")
+	if err != nil {
+		return fmt.Errorf("'watch' must be a boolean or an object of watch settings")
+	}
+	options := models.DefaultWatchOptions()
+	for key, value := range fields {
+		switch key {
+		case "enabled":
+			task.Watch, err = documentBool("watch.enabled", value)
+			recordOverride(task, "Watch", models.MergeReplace)
+		case "debounce":
+			options.Debounce, err = documentString("watch.debounce", value)
+		case "mode":
+			var mode string
+			if mode, err = documentString("watch.mode", value); err == nil {
+				switch models.WatchMode(mode) {
+				case models.WatchModeRestart, models.WatchModeQueue, models.WatchModeIgnoreWhileRunning:
+					options.Mode = models.WatchMode(mode)
+				default:
+					err = fmt.Errorf("unknown watch mode '%s', expected restart, queue or ignore-while-running", mode)
+				}
+			}
+		case "clear":
+			options.Clear, err = documentBool("watch.clear", value)
+		case "run_on_start":
+			options.RunOnStart, err = documentBool("watch.run_on_start", value)
+		case "interactive":
+			options.Interactive, err = documentBool("watch.interactive", value)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	task.WatchOptions = &options
+	recordOverride(task, "WatchOptions", models.MergeReplace)
+	return nil
+}
+
+func parseDocumentReady(task *models.Task, value any) error {
+	fields, err := documentObject("ready", value, "port", "http", "log", "command", "timeout", "interval")
+	if err != nil {
+		return err
+	}
+	ready := models.DefaultReadyCheck()
+	for key, value := range fields {
+		switch key {
+		case "port":
+			ready.Port, err = documentNumber("ready.port", value)
+		case "http":
+			ready.HTTP, err = documentString("ready.http", value)
+		case "log":
+			ready.Log, err = documentString("ready.log", value)
+		case "command":
+			ready.Command, err = documentString("ready.command", value)
+		case "timeout":
+			ready.Timeout, err = documentString("ready.timeout", value)
+		case "interval":
+			ready.Interval, err = documentString("ready.interval", value)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	task.Ready = &ready
+	return nil
+}
+
+func parseDocumentHooks(config *types.Config, value any) error {
+	hooks, ok := value.(map[string]any)
+	if !ok {
+		return fmt.Errorf("'hooks' must map names to objects")
+	}
+	for _, name := range sortedKeys(hooks) {
+		properties, ok := hooks[name].(map[string]any)
+		if !ok {
+			return fmt.Errorf("hooks.%s must be an object of properties", name)
+		}
+		hook := models.Hook{Name: name}
+		for _, property := range sortedKeys(properties) {
+			propDef, exists := hookPropertyRegistry[property]
+			if !exists {
+				return fmt.Errorf("hooks.%s: unknown property '%s'", name, property)
+			}
+			converted, err := documentValue(propDef.Type, property, properties[property])
+			if err == nil {
+				err = setFieldValue(reflect.ValueOf(&hook).Elem(), propDef.HookField, converted)
+			}
+			if err != nil {
+				return fmt.Errorf("hooks.%s: %v", name, err)
+			}
+		}
+		config.Hooks[name] = hook
+	}
+	return nil
+}
+
+// documentValue converts a decoded value to the Go type of a property.
+func documentValue(propType PropertyType, name string, value any) (any, error) {
+	switch propType {
+	case PropString:
+		return documentString(name, value)
+	case PropStringArray:
+		return documentStrings(name, value)
+	case PropStringMap:
+		return documentStringMap(name, value)
+	case PropBoolean:
+		return documentBool(name, value)
+	case PropNumber:
+		return documentNumber(name, value)
+	default:
+		return nil, fmt.Errorf("unknown property type")
+	}
+}
+
+func documentString(name string, value any) (string, error) {
+	s, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("'%s' must be a string", name)
+	}
+	return s, nil
+}
+
+func documentStrings(name string, value any) ([]string, error) {
+	items, ok := value.([]any)
+	if !ok {
+		return nil, fmt.Errorf("'%s' must be a list of strings", name)
+	}
+	result := make([]string, len(items))
+	for i, item := range items {
+		if result[i], ok = item.(string); !ok {
+			return nil, fmt.Errorf("'%s' must be a list of strings", name)
+		}
+	}
+	return result, nil
+}
+
+// documentStringMap accepts numbers and booleans as values, since YAML and
+// TOML do not quote them, as in PORT: 8080.
+func documentStringMap(name string, value any) (map[string]string, error) {
+	values, ok := value.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("'%s' must map names to values", name)
+	}
+	result := make(map[string]string, len(values))
+	for key, value := range values {
+		if result[key], ok = scalarString(value); !ok {
+			return nil, fmt.Errorf("'%s.%s' must be a string, number or boolean", name, key)
+		}
+	}
+	return result, nil
+}
+
+func documentBool(name string, value any) (bool, error) {
+	b, ok := value.(bool)
+	if !ok {
+		return false, fmt.Errorf("'%s' must be true or false", name)
+	}
+	return b, nil
+}
+
+func documentNumber(name string, value any) (int, error) {
+	switch n := value.(type) {
+	case int:
+		return n, nil
+	case int64:
+		return int(n), nil
+	case uint64:
+		return int(n), nil
+	case float64:
+		if n == math.Trunc(n) {
+			return int(n), nil
+		}
+	case json.Number:
+		if i, err := n.Int64(); err == nil {
+			return int(i), nil
+		}
+	}
+	return 0, fmt.Errorf("'%s' must be a whole number", name)
+}
+
+func scalarString(value any) (string, bool) {
+	switch v := value.(type) {
+	case string:
+		return v, true
+	case bool:
+		return strconv.FormatBool(v), true
+	case int, int64, uint64, json.Number:
+		return fmt.Sprint(v), true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	default:
+		return "", false
+	}
+}
+
+// documentObject returns the fields of an object, rejecting unknown ones.
+func documentObject(name string, value any, keys ...string) (map[string]any, error) {
+	fields, ok := value.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("'%s' must be an object", name)
+	}
+	for key := range fields {
+		if !slices.Contains(keys, key) {
+			return nil, fmt.Errorf("unknown key '%s' in '%s'", key, name)
+		}
+	}
+	return fields, nil
+}
+
+// documentList returns the items of a list. TOML decodes arrays of tables,
+// such as [[tasks.deploy.arg]], as a list of objects.
+func documentList(value any) ([]any, bool) {
+	switch items := value.(type) {
+	case []any:
+		return items, true
+	case []map[string]any:
+		result := make([]any, len(items))
+		for i, item := range items {
+			result[i] = item
+		}
+		return result, true
+	default:
+		return nil, false
+	}
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -42,13 +42,25 @@ func (l *Lexer) NextToken() Token {
 	case ',':
 		token = l.makeSingleCharToken(TOKEN_COMMA, line, column)
 	case '$':
-		token = l.makeSingleCharToken(TOKEN_DOLLAR, line, column)
+		if l.scanner.PeekChar() == '(' {
+			token = l.scanCommand(line, column)
+		} else {
+			token = l.makeSingleCharToken(TOKEN_DOLLAR, line, column)
+		}
 	case '(':
 		token = l.makeSingleCharToken(TOKEN_LPAREN, line, column)
 	case ')':
 		token = l.makeSingleCharToken(TOKEN_RPAREN, line, column)
 	case '=':
 		token = l.makeSingleCharToken(TOKEN_EQUALS, line, column)
+	case '+':
+		if l.scanner.PeekChar() == '=' {
+			l.scanner.ReadChar()
+			l.scanner.ReadChar()
+			token = NewTokenWithLiteral(TOKEN_PLUS_EQUALS, "+=", line, column)
+		} else {
+			token = l.makeSingleCharToken(TOKEN_ILLEGAL, line, column)
+		}
 	case '"':
 		if l.scanner.PeekChar() == '"' {
 			_, _, nextChar, _, _ := l.scanner.GetState()
@@ -116,6 +128,17 @@ func (l *Lexer) scanMultilineString(line, column int) Token {
 	return NewTokenWithLiteral(TOKEN_MULTILINE_STRING, literal, line, column)
 }
 
+// scanCommand scans a $(command). An unterminated one is an illegal "$("
+// token, so that the error points at where it starts.
+func (l *Lexer) scanCommand(line, column int) Token {
+	literal, terminated := l.scanner.ScanCommand()
+	if !terminated {
+		return NewTokenWithLiteral(TOKEN_ILLEGAL, "$(", line, column)
+	}
+	l.scanner.ReadChar()
+	return NewTokenWithLiteral(TOKEN_COMMAND, literal, line, column)
+}
+
 func (l *Lexer) scanComment(line, column int) Token {
 	literal := l.scanner.ScanComment()
 	return NewTokenWithLiteral(TOKEN_COMMENT, literal, line, column)
@@ -4,7 +4,6 @@ import (
 	"fmt"
 
 	"github.com/azuyamat/pace/internal/config/types"
-	"github.com/azuyamat/pace/internal/models"
 )
 
 type Parser struct {
@@ -66,14 +65,7 @@ func (p *Parser) isAtEnd() bool {
 }
 
 func (p *Parser) Parse() (*types.Config, error) {
-	config := &types.Config{
-		Tasks:     make(map[string]models.Task),
-		Hooks:     make(map[string]models.Hook),
-		Globals:   make(map[string]string),
-		Constants: make(map[string]string),
-		Aliases:   make(map[string]string),
-		Imports:   make([]string, 0),
-	}
+	config := types.NewConfig()
 
 	for !p.isAtEnd() {
 		p.skipInsignificantTokens()
@@ -39,16 +39,16 @@ var taskPropertyRegistry = map[string]PropertyDefinition{
 	"inputs":            prop(PropStringArray, "Inputs", "Input values must be strings, e.g., [\"src/main.go\", \"src/util.go\"]"),
 	"outputs":           prop(PropStringArray, "Outputs", "Output values must be strings, e.g., [\"bin/app\", \"bin/util\"]"),
 	"depends-on":        prop(PropStringArray, "DependsOn", "Task names must be strings, e.g., [build, test]"),
-	"dependencies":      prop(PropStringArray, "DependsOn", "Task names must be strings, e.g., [build, test]"),
 	"env":               prop(PropStringMap, "Env", ""),
+	"env_file":          prop(PropStringArray, "EnvFiles", "Env file paths must be strings, e.g., [\".env\", \".env.local\"]"),
 	"cache":             prop(PropBoolean, "Cache", ""),
 	"working_dir":       prop(PropString, "WorkingDir", "Working directory value must be a string, e.g., \"/app\""),
 	"requires":          prop(PropStringArray, "Requires", "Hook names must be strings, e.g., [setup, clean]"),
-	"before":            prop(PropStringArray, "Requires", "Hook names must be strings, e.g., [setup, clean]"),
 	"triggers":          prop(PropStringArray, "Triggers", "Hook names must be strings, e.g., [cleanup, notify]"),
-	"after":             prop(PropStringArray, "Triggers", "Hook names must be strings, e.g., [cleanup, notify]"),
 	"description":       prop(PropString, "Description", "Description values must be strings"),
-	"watch":             prop(PropBoolean, "Watch", ""),
+	"watch_inputs":      prop(PropStringArray, "WatchInputs", "Watch input values must be strings, e.g., [\"src/**/*.go\"]"),
+	"service":           prop(PropBoolean, "Service", ""),
+	"restart":           prop(PropString, "Restart", "Restart must be \"no\", \"on-failure\" or \"always\""),
 	"parallel":          prop(PropBoolean, "Parallel", ""),
 	"silent":            prop(PropBoolean, "Silent", ""),
 	"continue_on_error": prop(PropBoolean, "ContinueOnError", ""),
@@ -60,13 +60,43 @@ var taskPropertyRegistry = map[string]PropertyDefinition{
 	"when":              prop(PropString, "When", "Condition value must be a string"),
 	"args": {
 		Type:         PropCustom,
+		TaskField:    "Args",
 		CustomParser: (*PropertyParser).parseArgs,
 	},
+	"arg": {
+		Type:         PropCustom,
+		TaskField:    "Args",
+		CustomParser: (*PropertyParser).parseArg,
+	},
+	"watch": {
+		Type:         PropCustom,
+		CustomParser: (*PropertyParser).parseWatch,
+	},
+	"ready": {
+		Type:         PropCustom,
+		TaskField:    "Ready",
+		CustomParser: (*PropertyParser).parseReady,
+	},
+}
+
+// taskPropertyAliases maps alternative names of task properties to the
+// property they stand for.
+var taskPropertyAliases = map[string]string{
+	"dependencies": "depends-on",
+	"before":       "requires",
+	"after":        "triggers",
+}
+
+func init() {
+	for alias, name := range taskPropertyAliases {
+		taskPropertyRegistry[alias] = taskPropertyRegistry[name]
+	}
 }
 
 var hookPropertyRegistry = map[string]PropertyDefinition{
 	"command":     hookProp(PropString, "Command", "Command values must be strings, e.g., command \"echo setup\""),
 	"env":         hookProp(PropStringMap, "Env", ""),
+	"env_file":    hookProp(PropStringArray, "EnvFiles", "Env file paths must be strings, e.g., [\".env\"]"),
 	"working_dir": hookProp(PropString, "WorkingDir", "Working directory value must be a string"),
 	"description": hookProp(PropString, "Description", "Description values must be strings"),
 }
@@ -98,8 +128,25 @@ func (pp *PropertyParser) ParseTaskProperty(task *models.Task) error {
 
 	pp.parser.advance()
 
+	mode := models.MergeReplace
+	if pp.parser.currentToken.Is(TOKEN_PLUS_EQUALS) {
+		if propDef.Type != PropStringArray {
+			return pp.parser.createError(
+				fmt.Sprintf("Cannot append to '%s' property", propName),
+			).WithContext(fmt.Sprintf("Parsing '%s' property", propName)).WithHint("'+=' is only supported for list properties, e.g., inputs += [\"extra/**/*.go\"]")
+		}
+		mode = models.MergeAppend
+		pp.parser.advance()
+	}
+
 	if propDef.Type == PropCustom {
-		return propDef.CustomParser(pp, task)
+		if err := propDef.CustomParser(pp, task); err != nil {
+			return err
+		}
+		if propDef.TaskField != "" {
+			recordOverride(task, propDef.TaskField, mode)
+		}
+		return nil
 	}
 
 	value, err := pp.parseByType(propDef.Type, propName, propDef.Hint)
@@ -107,7 +154,18 @@ func (pp *PropertyParser) ParseTaskProperty(task *models.Task) error {
 		return err
 	}
 
-	return pp.setFieldValue(reflect.ValueOf(task).Elem(), propDef.TaskField, value)
+	if err := setFieldValue(reflect.ValueOf(task).Elem(), propDef.TaskField, value); err != nil {
+		return err
+	}
+	recordOverride(task, propDef.TaskField, mode)
+	return nil
+}
+
+func recordOverride(task *models.Task, field string, mode models.MergeMode) {
+	if task.Overrides == nil {
+		task.Overrides = make(map[string]models.MergeMode)
+	}
+	task.Overrides[field] = mode
 }
 
 func (pp *PropertyParser) ParseHookProperty(hook *models.Hook) error {
@@ -132,10 +190,10 @@ func (pp *PropertyParser) ParseHookProperty(hook *models.Hook) error {
 		return err
 	}
 
-	return pp.setFieldValue(reflect.ValueOf(hook).Elem(), propDef.HookField, value)
+	return setFieldValue(reflect.ValueOf(hook).Elem(), propDef.HookField, value)
 }
 
-func (pp *PropertyParser) setFieldValue(structValue reflect.Value, fieldName string, value any) error {
+func setFieldValue(structValue reflect.Value, fieldName string, value any) error {
 	field := structValue.FieldByName(fieldName)
 	if !field.IsValid() {
 		return fmt.Errorf("field %s not found", fieldName)
@@ -146,7 +204,11 @@ func (pp *PropertyParser) setFieldValue(structValue reflect.Value, fieldName str
 
 	fieldValue := reflect.ValueOf(value)
 	if field.Type() != fieldValue.Type() {
-		return fmt.Errorf("type mismatch for field %s: expected %s, got %s", fieldName, field.Type(), fieldValue.Type())
+		// Named types such as RestartPolicy are set from their underlying type.
+		if field.Kind() != fieldValue.Kind() || !fieldValue.CanConvert(field.Type()) {
+			return fmt.Errorf("type mismatch for field %s: expected %s, got %s", fieldName, field.Type(), fieldValue.Type())
+		}
+		fieldValue = fieldValue.Convert(field.Type())
 	}
 
 	field.Set(fieldValue)
@@ -178,9 +240,11 @@ func (pp *PropertyParser) parseArgs(task *models.Task) error {
 		return err
 	}
 
-	task.Args = &models.TaskArgs{
-		Required: []string{},
-		Optional: []string{},
+	if task.Args == nil {
+		task.Args = &models.TaskArgs{
+			Required: []string{},
+			Optional: []string{},
+		}
 	}
 
 	for !pp.parser.currentToken.Is(TOKEN_RBRACE) && !pp.parser.isAtEnd() {
@@ -224,3 +288,224 @@ func (pp *PropertyParser) parseArgs(task *models.Task) error {
 
 	return pp.parser.expect(TOKEN_RBRACE)
 }
+
+// parseArg parses a typed argument declaration:
+//
+//	arg env string default "dev" choices ["dev", "prod"]
+//
+// Arguments without a default are required, except booleans which default
+// to false.
+func (pp *PropertyParser) parseArg(task *models.Task) error {
+	name, err := pp.parser.expectIdentifier("argument name", "Argument declarations look like: arg env string default \"dev\"")
+	if err != nil {
+		return err
+	}
+
+	arg := models.TaskArg{Name: name, Type: models.ArgTypeString}
+
+	for pp.parser.currentToken.Is(TOKEN_IDENTIFIER) {
+		keyword := pp.parser.currentToken.Literal
+		switch keyword {
+		case string(models.ArgTypeString), string(models.ArgTypeInt), string(models.ArgTypeBool):
+			arg.Type = models.ArgType(keyword)
+			pp.parser.advance()
+
+		case "default":
+			pp.parser.advance()
+			if !pp.parser.currentToken.IsOneOf(TOKEN_STRING, TOKEN_IDENTIFIER, TOKEN_NUMBER, TOKEN_BOOLEAN) {
+				return pp.parser.createError(
+					fmt.Sprintf("Expected default value but got %s", pp.parser.currentToken.Type.String()),
+				).WithContext(fmt.Sprintf("Parsing argument '%s'", name)).WithHint("Default values must be strings, numbers or booleans, e.g., default \"dev\"")
+			}
+			arg.Default = pp.parser.currentToken.Literal
+			arg.HasDefault = true
+			pp.parser.advance()
+
+		case "choices":
+			pp.parser.advance()
+			choices, err := pp.parser.helper.ParseStringArray(
+				fmt.Sprintf("Parsing choices of argument '%s'", name),
+				"Choices must be strings, e.g., choices [\"dev\", \"prod\"]",
+			)
+			if err != nil {
+				return err
+			}
+			arg.Choices = choices
+
+		default:
+			// Not part of this declaration; the next task property starts here.
+			return pp.addArg(task, arg)
+		}
+	}
+
+	return pp.addArg(task, arg)
+}
+
+func (pp *PropertyParser) addArg(task *models.Task, arg models.TaskArg) error {
+	if task.Args == nil {
+		task.Args = &models.TaskArgs{
+			Required: []string{},
+			Optional: []string{},
+		}
+	}
+
+	if _, exists := task.Args.Lookup(arg.Name); exists {
+		return pp.parser.createError(
+			fmt.Sprintf("Argument '%s' is declared more than once", arg.Name),
+		).WithContext(fmt.Sprintf("Parsing task '%s'", task.Name))
+	}
+
+	task.Args.Declared = append(task.Args.Declared, arg)
+	if arg.HasDefault || arg.Type == models.ArgTypeBool {
+		task.Args.Optional = append(task.Args.Optional, arg.Name)
+	} else {
+		task.Args.Required = append(task.Args.Required, arg.Name)
+	}
+	return nil
+}
+
+// parseWatch parses either "watch true" or a block of watch settings:
+//
+//	watch { debounce "200ms" mode "queue" clear true run_on_start false interactive true }
+func (pp *PropertyParser) parseWatch(task *models.Task) error {
+	if !pp.parser.currentToken.Is(TOKEN_LBRACE) {
+		watch, err := pp.parser.helper.ParseBoolean("watch")
+		if err != nil {
+			return err
+		}
+		task.Watch = watch
+		recordOverride(task, "Watch", models.MergeReplace)
+		return nil
+	}
+
+	if err := pp.parser.expect(TOKEN_LBRACE); err != nil {
+		return err
+	}
+
+	options := models.DefaultWatchOptions()
+
+	for !pp.parser.currentToken.Is(TOKEN_RBRACE) && !pp.parser.isAtEnd() {
+		pp.parser.skipInsignificantTokens()
+
+		if pp.parser.currentToken.Is(TOKEN_RBRACE) {
+			break
+		}
+
+		if !pp.parser.currentToken.Is(TOKEN_IDENTIFIER) {
+			return pp.parser.createError(
+				fmt.Sprintf("Expected watch setting but got %s", pp.parser.currentToken.Type.String()),
+			).WithContext("Parsing 'watch' block").WithHint("Valid settings are 'debounce', 'mode', 'clear', 'run_on_start' and 'interactive'")
+		}
+
+		keyword := pp.parser.currentToken.Literal
+		pp.parser.advance()
+
+		switch keyword {
+		case "debounce":
+			debounce, err := pp.parser.helper.ParseString(keyword, "Debounce values must be strings like \"200ms\", \"1s\"")
+			if err != nil {
+				return err
+			}
+			options.Debounce = debounce
+
+		case "mode":
+			mode, err := pp.parser.helper.ParseString(keyword, "Mode must be \"restart\", \"queue\" or \"ignore-while-running\"")
+			if err != nil {
+				return err
+			}
+			switch models.WatchMode(mode) {
+			case models.WatchModeRestart, models.WatchModeQueue, models.WatchModeIgnoreWhileRunning:
+				options.Mode = models.WatchMode(mode)
+			default:
+				return pp.parser.createError(
+					fmt.Sprintf("Unknown watch mode: %s", mode),
+				).WithContext("Parsing 'watch' block").WithHint("Mode must be \"restart\", \"queue\" or \"ignore-while-running\"")
+			}
+
+		case "clear":
+			clear, err := pp.parser.helper.ParseBoolean(keyword)
+			if err != nil {
+				return err
+			}
+			options.Clear = clear
+
+		case "run_on_start":
+			runOnStart, err := pp.parser.helper.ParseBoolean(keyword)
+			if err != nil {
+				return err
+			}
+			options.RunOnStart = runOnStart
+
+		case "interactive":
+			interactive, err := pp.parser.helper.ParseBoolean(keyword)
+			if err != nil {
+				return err
+			}
+			options.Interactive = interactive
+
+		default:
+			return pp.parser.createError(
+				fmt.Sprintf("Unknown watch setting: %s", keyword),
+			).WithContext("Parsing 'watch' block").WithHint("Valid settings are 'debounce', 'mode', 'clear', 'run_on_start' and 'interactive'")
+		}
+	}
+
+	task.WatchOptions = &options
+	recordOverride(task, "WatchOptions", models.MergeReplace)
+	return pp.parser.expect(TOKEN_RBRACE)
+}
+
+// parseReady parses the readiness probes of a service task:
+//
+//	ready { port 5432 http "http://localhost:8080/health" log "listening on" command "pg_isready" timeout "1m" interval "500ms" }
+func (pp *PropertyParser) parseReady(task *models.Task) error {
+	if err := pp.parser.expect(TOKEN_LBRACE); err != nil {
+		return err
+	}
+
+	ready := models.DefaultReadyCheck()
+	const hint = "Valid settings are 'port', 'http', 'log', 'command', 'timeout' and 'interval'"
+
+	for !pp.parser.currentToken.Is(TOKEN_RBRACE) && !pp.parser.isAtEnd() {
+		pp.parser.skipInsignificantTokens()
+
+		if pp.parser.currentToken.Is(TOKEN_RBRACE) {
+			break
+		}
+
+		if !pp.parser.currentToken.Is(TOKEN_IDENTIFIER) {
+			return pp.parser.createError(
+				fmt.Sprintf("Expected ready setting but got %s", pp.parser.currentToken.Type.String()),
+			).WithContext("Parsing 'ready' block").WithHint(hint)
+		}
+
+		keyword := pp.parser.currentToken.Literal
+		pp.parser.advance()
+
+		var err error
+		switch keyword {
+		case "port":
+			ready.Port, err = pp.parser.helper.ParseNumber(keyword)
+		case "http":
+			ready.HTTP, err = pp.parser.helper.ParseString(keyword, "HTTP checks take a URL, e.g., http \"http://localhost:8080/health\"")
+		case "log":
+			ready.Log, err = pp.parser.helper.ParseString(keyword, "Log checks take the text to wait for, e.g., log \"listening on\"")
+		case "command":
+			ready.Command, err = pp.parser.helper.ParseString(keyword, "Command checks take a shell command, e.g., command \"pg_isready\"")
+		case "timeout":
+			ready.Timeout, err = pp.parser.helper.ParseString(keyword, "Timeout values must be strings like \"30s\", \"2m\"")
+		case "interval":
+			ready.Interval, err = pp.parser.helper.ParseString(keyword, "Interval values must be strings like \"250ms\", \"1s\"")
+		default:
+			return pp.parser.createError(
+				fmt.Sprintf("Unknown ready setting: %s", keyword),
+			).WithContext("Parsing 'ready' block").WithHint(hint)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	task.Ready = &ready
+	return pp.parser.expect(TOKEN_RBRACE)
+}
@@ -39,9 +39,12 @@ func (s *Scanner) PeekChar() byte {
 	return s.input[s.readPosition]
 }
 
+// ScanIdentifier scans a name. Names may contain dots after the first
+// character, so that definitions of aliased imports, like ci.lint, can be
+// referenced.
 func (s *Scanner) ScanIdentifier() string {
 	position := s.position
-	for isLetter(s.char) {
+	for isLetter(s.char) || (s.char == '.' && s.position > position) {
 		s.ReadChar()
 	}
 	return s.input[position:s.position]
@@ -66,10 +69,19 @@ func (s *Scanner) ScanString() string {
 	return s.input[position:s.position]
 }
 
+// ScanMultilineString scans a """ string, starting on its second quote. A
+// newline right after the opening quotes is not part of the string.
 func (s *Scanner) ScanMultilineString() string {
 	s.ReadChar()
 	s.ReadChar()
-	s.ReadChar()
+	if s.char == '\r' && s.PeekChar() == '\n' {
+		s.ReadChar()
+	}
+	if s.char == '\n' {
+		s.line++
+		s.column = 0
+		s.ReadChar()
+	}
 
 	position := s.position
 
@@ -95,6 +107,44 @@ func (s *Scanner) ScanMultilineString() string {
 	return s.input[position:s.position]
 }
 
+// ScanCommand scans a $( ... ) command substitution and returns the command
+// inside it, and whether its closing parenthesis was found. Parentheses
+// inside quotes or nested substitutions are balanced.
+func (s *Scanner) ScanCommand() (string, bool) {
+	s.ReadChar()
+	s.ReadChar()
+
+	position := s.position
+	depth := 1
+	var quote byte
+
+	for s.char != 0 {
+		switch {
+		case quote != 0:
+			if s.char == quote {
+				quote = 0
+			}
+		case s.char == '\'' || s.char == '"':
+			quote = s.char
+		case s.char == '(':
+			depth++
+		case s.char == ')':
+			depth--
+			if depth == 0 {
+				return s.input[position:s.position], true
+			}
+		}
+
+		if s.char == '\n' {
+			s.line++
+			s.column = 0
+		}
+		s.ReadChar()
+	}
+
+	return s.input[position:s.position], false
+}
+
 func (s *Scanner) ScanComment() string {
 	position := s.position
 	for s.char != '\n' && s.char != 0 {
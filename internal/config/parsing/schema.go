@@ -0,0 +1,251 @@
+package parsing
+
+import (
+	"sort"
+
+	"github.com/azuyamat/pace/internal/config/types"
+	"github.com/azuyamat/pace/internal/models"
+)
+
+// SchemaURL is the JSON Schema dialect of the schema returned by Schema.
+const SchemaURL = "https://json-schema.org/draft/2020-12/schema"
+
+// customPropertySchemas describe the task properties read by a custom
+// parser.
+var customPropertySchemas = map[string]map[string]any{
+	"args": {
+		"type":        "object",
+		"description": "Untyped arguments, passed as $name in the command",
+		"properties": map[string]any{
+			"required": stringArraySchema(),
+			"optional": stringArraySchema(),
+		},
+		"additionalProperties": false,
+	},
+	"arg": {
+		"type":        "array",
+		"description": "Typed argument declarations",
+		"items": map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"name":    map[string]any{"type": "string"},
+				"type":    map[string]any{"enum": []models.ArgType{models.ArgTypeString, models.ArgTypeInt, models.ArgTypeBool}},
+				"default": map[string]any{"type": []string{"string", "number", "boolean"}},
+				"choices": stringArraySchema(),
+			},
+			"required":             []string{"name"},
+			"additionalProperties": false,
+		},
+	},
+	"watch": {
+		"description": "Re-run the task when its inputs change, with optional watch settings",
+		"oneOf": []any{
+			map[string]any{"type": "boolean"},
+			map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"enabled":      map[string]any{"type": "boolean"},
+					"debounce":     map[string]any{"type": "string"},
+					"mode":         map[string]any{"enum": []models.WatchMode{models.WatchModeRestart, models.WatchModeQueue, models.WatchModeIgnoreWhileRunning}},
+					"clear":        map[string]any{"type": "boolean"},
+					"run_on_start": map[string]any{"type": "boolean"},
+					"interactive":  map[string]any{"type": "boolean"},
+				},
+				"additionalProperties": false,
+			},
+		},
+	},
+	"ready": {
+		"type":        "object",
+		"description": "Probes telling when a service is ready for its dependents",
+		"properties": map[string]any{
+			"port":     map[string]any{"type": "integer"},
+			"http":     map[string]any{"type": "string"},
+			"log":      map[string]any{"type": "string"},
+			"command":  map[string]any{"type": "string"},
+			"timeout":  map[string]any{"type": "string"},
+			"interval": map[string]any{"type": "string"},
+		},
+		"additionalProperties": false,
+	},
+}
+
+// propertyEnums are the values accepted by string properties that take one
+// of a fixed set.
+var propertyEnums = map[string][]string{
+	"restart": {string(models.RestartNever), string(models.RestartOnFailure), string(models.RestartAlways)},
+}
+
+// Schema returns a JSON Schema of YAML, TOML and JSON config files. Task
+// and hook properties come from the property registries; aliases such as
+// "before" carry "x-alias-of" naming the property they stand for, which in
+// turn lists them in "x-aliases".
+func Schema() map[string]any {
+	task := propertiesSchema(taskPropertyRegistry, taskPropertyAliases)
+	properties := task["properties"].(map[string]any)
+	properties["alias"] = map[string]any{"type": "string", "description": "Another name the task can be run by"}
+	properties["extends"] = map[string]any{"type": "string", "description": "Template the task inherits its properties from"}
+	for name, propDef := range taskPropertyRegistry {
+		if propDef.Type == PropStringArray {
+			properties[name] = appendableSchema(properties[name].(map[string]any))
+		}
+	}
+
+	hook := propertiesSchema(hookPropertyRegistry, nil)
+
+	importKinds := make([]types.ImportKind, 0, len(types.ImportKinds))
+	for _, kind := range types.ImportKinds {
+		if kind != types.ImportConfig {
+			importKinds = append(importKinds, kind)
+		}
+	}
+
+	return map[string]any{
+		"$schema":              SchemaURL,
+		"title":                "pace configuration",
+		"type":                 "object",
+		"additionalProperties": false,
+		"properties": map[string]any{
+			"default": map[string]any{"type": "string", "description": "Task run when no task is named"},
+			"imports": map[string]any{
+				"type": "array",
+				"items": map[string]any{
+					"oneOf": []any{
+						map[string]any{"type": "string"},
+						map[string]any{
+							"type": "object",
+							"properties": map[string]any{
+								"path": map[string]any{"type": "string"},
+								"as":   map[string]any{"type": "string"},
+								"kind": map[string]any{"enum": importKinds},
+							},
+							"required":             []string{"path"},
+							"additionalProperties": false,
+						},
+					},
+				},
+			},
+			"env_file": stringArraySchema(),
+			"vars": map[string]any{
+				"type": "object",
+				"additionalProperties": map[string]any{
+					"oneOf": []any{
+						map[string]any{"type": []string{"string", "number", "boolean"}},
+						map[string]any{
+							"type":                 "object",
+							"properties":           map[string]any{"sh": map[string]any{"type": "string"}},
+							"required":             []string{"sh"},
+							"additionalProperties": false,
+						},
+					},
+				},
+			},
+			"globals": stringMapSchema(),
+			"aliases": map[string]any{"type": "object", "additionalProperties": map[string]any{"type": "string"}},
+			"groups":  map[string]any{"type": "object", "additionalProperties": stringArraySchema()},
+			"workspace": map[string]any{
+				"type":                 "object",
+				"properties":           map[string]any{"members": stringArraySchema()},
+				"required":             []string{"members"},
+				"additionalProperties": false,
+			},
+			"templates": map[string]any{"type": "object", "additionalProperties": map[string]any{"$ref": "#/$defs/task"}},
+			"tasks":     map[string]any{"type": "object", "additionalProperties": map[string]any{"$ref": "#/$defs/task"}},
+			"hooks":     map[string]any{"type": "object", "additionalProperties": map[string]any{"$ref": "#/$defs/hook"}},
+		},
+		"$defs": map[string]any{
+			"task": task,
+			"hook": hook,
+		},
+	}
+}
+
+func propertiesSchema(registry map[string]PropertyDefinition, aliases map[string]string) map[string]any {
+	properties := make(map[string]any, len(registry))
+	for name, propDef := range registry {
+		var schema map[string]any
+		if custom, exists := customPropertySchemas[name]; exists && propDef.Type == PropCustom {
+			schema = make(map[string]any, len(custom))
+			for key, value := range custom {
+				schema[key] = value
+			}
+		} else {
+			schema = propertyTypeSchema(propDef.Type)
+		}
+		if propDef.Hint != "" {
+			schema["description"] = propDef.Hint
+		}
+		if values, exists := propertyEnums[name]; exists {
+			schema["enum"] = values
+		}
+
+		if target, isAlias := aliases[name]; isAlias {
+			schema["x-alias-of"] = target
+		}
+		names := make([]string, 0)
+		for alias, target := range aliases {
+			if target == name {
+				names = append(names, alias)
+			}
+		}
+		if len(names) > 0 {
+			sort.Strings(names)
+			schema["x-aliases"] = names
+		}
+		properties[name] = schema
+	}
+
+	return map[string]any{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+}
+
+func propertyTypeSchema(propType PropertyType) map[string]any {
+	switch propType {
+	case PropString:
+		return map[string]any{"type": "string"}
+	case PropStringArray:
+		return stringArraySchema()
+	case PropStringMap:
+		return stringMapSchema()
+	case PropBoolean:
+		return map[string]any{"type": "boolean"}
+	case PropNumber:
+		return map[string]any{"type": "integer"}
+	default:
+		return map[string]any{}
+	}
+}
+
+// appendableSchema accepts a task list property either as a list or as
+// {append: [...]}, the document form of "+=".
+func appendableSchema(schema map[string]any) map[string]any {
+	result := make(map[string]any, len(schema))
+	for key, value := range schema {
+		if key != "type" && key != "items" {
+			result[key] = value
+		}
+	}
+	result["oneOf"] = []any{
+		stringArraySchema(),
+		map[string]any{
+			"type":                 "object",
+			"properties":           map[string]any{"append": stringArraySchema()},
+			"required":             []string{"append"},
+			"additionalProperties": false,
+		},
+	}
+	return result
+}
+
+func stringArraySchema() map[string]any {
+	return map[string]any{"type": "array", "items": map[string]any{"type": "string"}}
+}
+
+// stringMapSchema accepts numbers and booleans as values, like the config
+// files themselves.
+func stringMapSchema() map[string]any {
+	return map[string]any{"type": "object", "additionalProperties": map[string]any{"type": []string{"string", "number", "boolean"}}}
+}
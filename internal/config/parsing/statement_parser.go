@@ -25,17 +25,6 @@ type SimpleStatementDef struct {
 }
 
 var simpleStatements = map[string]SimpleStatementDef{
-	"var": {
-		Arg1Type:    ExpectIdentifier,
-		Arg1Hint:    "Variable names must be identifiers, e.g., var output = \"bin/app\"",
-		Arg2Type:    ptr(ExpectString),
-		Arg2Hint:    "Variable values must be strings",
-		NeedsEquals: true,
-		Handler: func(p *Parser, config *types.Config, name, value string) error {
-			config.Constants[name] = value
-			return nil
-		},
-	},
 	"default": {
 		Arg1Type: ExpectIdentifier,
 		Arg1Hint: "Default task name must be an identifier, e.g., default build",
@@ -54,14 +43,6 @@ var simpleStatements = map[string]SimpleStatementDef{
 			return nil
 		},
 	},
-	"import": {
-		Arg1Type: ExpectString,
-		Arg1Hint: "Import paths must be strings, e.g., import \"tasks/build.pace\"",
-		Handler: func(p *Parser, config *types.Config, importPath, _ string) error {
-			config.Imports = append(config.Imports, importPath)
-			return nil
-		},
-	},
 }
 
 func ptr[T any](v T) *T { return &v }
@@ -69,12 +50,20 @@ func ptr[T any](v T) *T { return &v }
 type StatementHandler func(p *Parser, config *types.Config) error
 
 var statementRegistry = map[string]StatementHandler{
-	"task":    (*Parser).parseTaskStatement,
-	"hook":    (*Parser).parseHookStatement,
-	"var":     (*Parser).parseSimpleStatement,
-	"default": (*Parser).parseSimpleStatement,
-	"alias":   (*Parser).parseSimpleStatement,
-	"import":  (*Parser).parseSimpleStatement,
+	"task":           (*Parser).parseTaskStatement,
+	"template":       (*Parser).parseTemplateStatement,
+	"hook":           (*Parser).parseHookStatement,
+	"var":            (*Parser).parseVarStatement,
+	"default":        (*Parser).parseSimpleStatement,
+	"alias":          (*Parser).parseSimpleStatement,
+	"import":         (*Parser).parseImportStatement,
+	"import_scripts": (*Parser).parseImportStatement,
+	"import_make":    (*Parser).parseImportStatement,
+	"import_cargo":   (*Parser).parseImportStatement,
+	"env_file":       (*Parser).parseEnvFileStatement,
+	"globals":        (*Parser).parseGlobalsStatement,
+	"group":          (*Parser).parseGroupStatement,
+	"workspace":      (*Parser).parseWorkspaceStatement,
 }
 
 func (p *Parser) parseTopLevelStatement(config *types.Config) error {
@@ -121,6 +110,59 @@ func (p *Parser) parseSimpleStatement(config *types.Config) error {
 	return def.Handler(p, config, arg1, arg2)
 }
 
+func (p *Parser) parseVarStatement(config *types.Config) error {
+	line := p.currentToken.Line
+	p.advance()
+
+	name, err := p.expectIdentifier("identifier", "Variable names must be identifiers, e.g., var output = \"bin/app\"")
+	if err != nil {
+		return err
+	}
+	config.VarSources[name] = models.Source{Line: line}
+
+	if err := p.expect(TOKEN_EQUALS); err != nil {
+		return p.createError(
+			fmt.Sprintf("Expected '=' but got %s", p.currentToken.Type.String()),
+		).WithContext("Parsing var statement")
+	}
+
+	switch {
+	case p.currentToken.Is(TOKEN_COMMAND):
+		config.DynamicVars[name] = types.DynamicVar{Name: name, Command: p.currentToken.Literal, Source: models.Source{Line: p.currentToken.Line}}
+		delete(config.Constants, name)
+		p.advance()
+
+	case p.currentToken.Is(TOKEN_ILLEGAL) && p.currentToken.Literal == "$(":
+		return p.createError("Unterminated $( command").
+			WithContext("Parsing var statement").
+			WithHint("Close the command with ')', e.g., var version = $(git describe --tags)")
+
+	case p.currentToken.IsKeyword("sh") && p.peekToken.Is(TOKEN_LPAREN):
+		line := p.currentToken.Line
+		p.advance()
+		p.advance()
+		command, err := p.expectString("command", "sh() takes a command string, e.g., var version = sh(\"git describe --tags\")")
+		if err != nil {
+			return err
+		}
+		if err := p.expect(TOKEN_RPAREN); err != nil {
+			return err
+		}
+		config.DynamicVars[name] = types.DynamicVar{Name: name, Command: command, Source: models.Source{Line: line}}
+		delete(config.Constants, name)
+
+	default:
+		value, err := p.expectString("string", "Variable values must be strings, $(command) or sh(\"command\")")
+		if err != nil {
+			return err
+		}
+		config.Constants[name] = value
+		delete(config.DynamicVars, name)
+	}
+
+	return nil
+}
+
 func (p *Parser) parseTaskStatement(config *types.Config) error {
 	task, err := p.parseTask()
 	if err != nil {
@@ -134,6 +176,150 @@ func (p *Parser) parseTaskStatement(config *types.Config) error {
 	return nil
 }
 
+func (p *Parser) parseTemplateStatement(config *types.Config) error {
+	template, err := p.parseTemplate()
+	if err != nil {
+		return err
+	}
+	config.Templates[template.Name] = template
+	return nil
+}
+
+// parseImportStatement parses an import, optionally under an alias, of a
+// config file or of a file of another tool:
+//
+//	import "ci/tasks.pace" as ci
+//	import_scripts "web/package.json" as web
+func (p *Parser) parseImportStatement(config *types.Config) error {
+	keyword := p.currentToken.Literal
+	line := p.currentToken.Line
+	p.advance()
+
+	path, err := p.expectString("import path", fmt.Sprintf("Import paths must be strings, e.g., %s \"tasks/build.pace\"", keyword))
+	if err != nil {
+		return err
+	}
+
+	imp := types.Import{Path: path, Line: line}
+	for _, kind := range types.ImportKinds {
+		if kind.Keyword() == keyword {
+			imp.Kind = kind
+		}
+	}
+	if p.currentToken.IsKeyword("as") {
+		p.advance()
+		if imp.Alias, err = p.expectIdentifier("import alias", "Aliases are identifiers, e.g., import \"ci/tasks.pace\" as ci"); err != nil {
+			return err
+		}
+	}
+	config.Imports = append(config.Imports, imp)
+	return nil
+}
+
+func (p *Parser) parseEnvFileStatement(config *types.Config) error {
+	p.advance()
+
+	files, err := p.helper.ParseStringArray("Parsing env_file statement", "Env file paths must be strings, e.g., env_file [\".env\", \".env.local\"]")
+	if err != nil {
+		return err
+	}
+	config.EnvFiles = append(config.EnvFiles, files...)
+	return nil
+}
+
+// parseGlobalsStatement parses a block of global settings. The '=' between
+// a key and its value is optional:
+//
+//	globals { "SHELL" "bash" watch_poll = "2s" }
+func (p *Parser) parseGlobalsStatement(config *types.Config) error {
+	p.advance()
+
+	if err := p.expect(TOKEN_LBRACE); err != nil {
+		return err
+	}
+
+	for !p.currentToken.Is(TOKEN_RBRACE) && !p.isAtEnd() {
+		p.skipInsignificantTokens()
+
+		if p.currentToken.Is(TOKEN_RBRACE) {
+			break
+		}
+
+		key, err := p.expectIdentifierOrString("global name", "Globals look like: globals { \"SHELL\" \"bash\" }")
+		if err != nil {
+			return err
+		}
+
+		if p.currentToken.Is(TOKEN_EQUALS) {
+			p.advance()
+		}
+
+		if !p.currentToken.IsOneOf(TOKEN_STRING, TOKEN_IDENTIFIER, TOKEN_BOOLEAN, TOKEN_NUMBER) {
+			return p.createError(
+				fmt.Sprintf("Expected value for global '%s' but got %s", key, p.currentToken.Type.String()),
+			).WithContext("Parsing globals block").WithHint("Global values must be strings, e.g., \"SHELL\" \"bash\"")
+		}
+		config.Globals[key] = p.currentToken.Literal
+		p.advance()
+	}
+
+	return p.expect(TOKEN_RBRACE)
+}
+
+// parseGroupStatement parses a named list of tasks started by "pace up":
+//
+//	group dev [api, web, worker]
+func (p *Parser) parseGroupStatement(config *types.Config) error {
+	p.advance()
+
+	name, err := p.expectIdentifier("group name", "Groups look like: group dev [api, web]")
+	if err != nil {
+		return err
+	}
+
+	members, err := p.helper.ParseStringArray(fmt.Sprintf("Parsing group '%s'", name), "Group members must be task names, e.g., group dev [api, web]")
+	if err != nil {
+		return err
+	}
+	config.Groups[name] = members
+	return nil
+}
+
+// parseWorkspaceStatement parses the members of a monorepo root:
+//
+//	workspace { members ["services/*", "libs/*"] }
+func (p *Parser) parseWorkspaceStatement(config *types.Config) error {
+	p.advance()
+
+	if err := p.expect(TOKEN_LBRACE); err != nil {
+		return err
+	}
+	if config.Workspace == nil {
+		config.Workspace = &types.Workspace{}
+	}
+
+	for !p.currentToken.Is(TOKEN_RBRACE) && !p.isAtEnd() {
+		p.skipInsignificantTokens()
+
+		if p.currentToken.Is(TOKEN_RBRACE) {
+			break
+		}
+
+		if !p.currentToken.IsKeyword("members") {
+			return p.unexpectedTokenError("workspace block")
+		}
+		p.advance()
+
+		patterns, err := p.helper.ParseStringArray("Parsing workspace members", "Members are directory globs, e.g., members [\"services/*\", \"libs/*\"]")
+		if err != nil {
+			return err
+		}
+		config.Workspace.Patterns = append(config.Workspace.Patterns, patterns...)
+	}
+
+	return p.expect(TOKEN_RBRACE)
+}
+
 func (p *Parser) parseHookStatement(config *types.Config) error {
 	hook, err := p.parseHook()
 	if err != nil {
@@ -190,7 +376,8 @@ func (p *Parser) expectIdentifierOrString(contextName, hint string) (string, err
 }
 
 func (p *Parser) parseTask() (models.Task, error) {
-	var task models.Task
+	task := models.Task{Overrides: make(map[string]models.MergeMode)}
+	task.Source.Line = p.currentToken.Line
 
 	p.advance()
 
@@ -217,6 +404,10 @@ func (p *Parser) parseTask() (models.Task, error) {
 		}
 	}
 
+	if err := p.parseExtends(&task); err != nil {
+		return task, err
+	}
+
 	if err := p.expect(TOKEN_LBRACE); err != nil {
 		return task, err
 	}
@@ -232,6 +423,55 @@ func (p *Parser) parseTask() (models.Task, error) {
 	return task, nil
 }
 
+func (p *Parser) parseTemplate() (models.Task, error) {
+	template := models.Task{Overrides: make(map[string]models.MergeMode)}
+	template.Source.Line = p.currentToken.Line
+
+	p.advance()
+
+	if p.currentToken.Type != TOKEN_IDENTIFIER {
+		return template, p.createError(
+			fmt.Sprintf("Expected template name (identifier) but got %s", p.currentToken.Type.String()),
+		).WithContext("Parsing template definition").WithHint("Template names must be identifiers, e.g., template go_base { ... }")
+	}
+	template.Name = p.currentToken.Literal
+	p.advance()
+
+	if err := p.parseExtends(&template); err != nil {
+		return template, err
+	}
+
+	if err := p.expect(TOKEN_LBRACE); err != nil {
+		return template, err
+	}
+
+	if err := p.parseTaskBody(&template); err != nil {
+		return template, err
+	}
+
+	if err := p.expect(TOKEN_RBRACE); err != nil {
+		return template, err
+	}
+
+	return template, nil
+}
+
+// parseExtends handles the optional "extends <template>" clause that may
+// follow a task or template name.
+func (p *Parser) parseExtends(task *models.Task) error {
+	if !p.currentToken.IsKeyword("extends") {
+		return nil
+	}
+	p.advance()
+
+	name, err := p.expectIdentifier("template name", "Extends must name a template, e.g., task build extends go_base { ... }")
+	if err != nil {
+		return err
+	}
+	task.Extends = name
+	return nil
+}
+
 func (p *Parser) parseTaskBody(task *models.Task) error {
 	for !p.currentToken.Is(TOKEN_RBRACE) && !p.isAtEnd() {
 		p.skipInsignificantTokens()
@@ -249,6 +489,7 @@ func (p *Parser) parseTaskBody(task *models.Task) error {
 
 func (p *Parser) parseHook() (models.Hook, error) {
 	var hook models.Hook
+	hook.Source.Line = p.currentToken.Line
 
 	p.advance()
 
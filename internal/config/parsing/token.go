@@ -13,6 +13,7 @@ const (
 	TOKEN_IDENTIFIER
 	TOKEN_STRING
 	TOKEN_MULTILINE_STRING
+	TOKEN_COMMAND
 	TOKEN_NUMBER
 	TOKEN_BOOLEAN
 
@@ -25,6 +26,7 @@ const (
 	TOKEN_LPAREN
 	TOKEN_RPAREN
 	TOKEN_EQUALS
+	TOKEN_PLUS_EQUALS
 
 	TOKEN_COMMENT
 	TOKEN_NEWLINE
@@ -73,6 +75,8 @@ func (tokenType TokenType) String() string {
 		return "STRING"
 	case TOKEN_MULTILINE_STRING:
 		return "MULTILINE_STRING"
+	case TOKEN_COMMAND:
+		return "COMMAND"
 	case TOKEN_NUMBER:
 		return "NUMBER"
 	case TOKEN_BOOLEAN:
@@ -95,6 +99,8 @@ func (tokenType TokenType) String() string {
 		return "RPAREN"
 	case TOKEN_EQUALS:
 		return "EQUALS"
+	case TOKEN_PLUS_EQUALS:
+		return "PLUS_EQUALS"
 	case TOKEN_COMMENT:
 		return "COMMENT"
 	case TOKEN_NEWLINE:
@@ -3,9 +3,12 @@ package processing
 import (
 	"os"
 	"regexp"
+	"slices"
+	"strconv"
 
 	"github.com/azuyamat/pace/internal/config/types"
 	"github.com/azuyamat/pace/internal/logger"
+	"github.com/azuyamat/pace/internal/models"
 )
 
 var varPattern = regexp.MustCompile(`\$\{([^}]+)\}`)
@@ -23,10 +26,27 @@ func NewResolver(config *types.Config) *Resolver {
 }
 
 func (r *Resolver) ResolveString(input string) string {
+	return r.resolve(input, nil)
+}
+
+// ResolveCommand resolves variables in a task command, leaving the argument
+// placeholders ${1}, ${@}, ${@:raw} and ${name} for the runner to fill in.
+func (r *Resolver) ResolveCommand(input string, args *models.TaskArgs) string {
+	return r.resolve(input, func(varName string) bool {
+		return isArgPlaceholder(varName, args)
+	})
+}
+
+func (r *Resolver) resolve(input string, skip func(varName string) bool) string {
 	return varPattern.ReplaceAllStringFunc(input, func(match string) string {
 		varName := match[2 : len(match)-1]
 
+		if skip != nil && skip(varName) {
+			return match
+		}
+
 		if value, exists := r.config.Constants[varName]; exists {
+			r.config.UsedVars[varName] = true
 			return value
 		}
 
@@ -34,6 +54,16 @@ func (r *Resolver) ResolveString(input string) string {
 			return value
 		}
 
+		if _, exists := r.config.DynamicVars[varName]; exists {
+			// Evaluated by the runner when a task referencing it runs.
+			r.config.UsedVars[varName] = true
+			return match
+		}
+
+		if value, exists := r.config.DotEnv[varName]; exists {
+			return value
+		}
+
 		if value := os.Getenv(varName); value != "" {
 			return value
 		}
@@ -47,6 +77,19 @@ func (r *Resolver) ResolveString(input string) string {
 	})
 }
 
+func isArgPlaceholder(varName string, args *models.TaskArgs) bool {
+	if varName == "@" || varName == "@:raw" {
+		return true
+	}
+	if _, err := strconv.Atoi(varName); err == nil {
+		return true
+	}
+	if args == nil {
+		return false
+	}
+	return slices.Contains(args.Required, varName) || slices.Contains(args.Optional, varName)
+}
+
 func (r *Resolver) ResolveStringSlice(slice []string) []string {
 	result := make([]string, len(slice))
 	for i, s := range slice {
@@ -0,0 +1,173 @@
+package processing
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/azuyamat/pace/internal/config/types"
+	"github.com/azuyamat/pace/internal/models"
+)
+
+// nonInheritedFields are task fields that always belong to the task itself
+// and are never copied from the template it extends.
+var nonInheritedFields = map[string]bool{
+	"Name":      true,
+	"Alias":     true,
+	"Extends":   true,
+	"Source":    true,
+	"Overrides": true,
+	"ExtraArgs": true,
+	"ArgValues": true,
+}
+
+type TemplateResolver struct {
+	config    *types.Config
+	resolved  map[string]models.Task
+	resolving map[string]bool
+}
+
+func NewTemplateResolver(config *types.Config) *TemplateResolver {
+	return &TemplateResolver{
+		config:    config,
+		resolved:  make(map[string]models.Task),
+		resolving: make(map[string]bool),
+	}
+}
+
+// Resolve merges every template into the tasks and templates extending it.
+// Scalars set in the task override the template, lists replace the template
+// value unless appended with +=, and maps are merged key by key.
+func (tr *TemplateResolver) Resolve() error {
+	for _, name := range sortedNames(tr.config.Templates) {
+		template, err := tr.resolveTemplate(name)
+		if err != nil {
+			return err
+		}
+		tr.config.Templates[name] = template
+	}
+
+	for _, name := range sortedNames(tr.config.Tasks) {
+		task := tr.config.Tasks[name]
+		if task.Overrides == nil {
+			// Already merged when the workspace member defining it was
+			// loaded, or not written in a config file.
+			continue
+		}
+		if task.Extends != "" {
+			if _, exists := tr.config.Templates[task.Extends]; !exists {
+				return fmt.Errorf("task '%s' extends non-existent template '%s'", name, task.Extends)
+			}
+			base, err := tr.resolveTemplate(task.Extends)
+			if err != nil {
+				return err
+			}
+			task = mergeTask(base, task)
+		}
+		task.Overrides = nil
+		tr.config.Tasks[name] = task
+	}
+
+	return nil
+}
+
+func (tr *TemplateResolver) resolveTemplate(name string) (models.Task, error) {
+	if template, done := tr.resolved[name]; done {
+		return template, nil
+	}
+
+	template := tr.config.Templates[name]
+	if template.Overrides == nil {
+		tr.resolved[name] = template
+		return template, nil
+	}
+
+	if tr.resolving[name] {
+		return models.Task{}, fmt.Errorf("circular template inheritance detected at template '%s'", name)
+	}
+	tr.resolving[name] = true
+	defer delete(tr.resolving, name)
+
+	if template.Extends != "" {
+		if _, exists := tr.config.Templates[template.Extends]; !exists {
+			return models.Task{}, fmt.Errorf("template '%s' extends non-existent template '%s'", name, template.Extends)
+		}
+		base, err := tr.resolveTemplate(template.Extends)
+		if err != nil {
+			return models.Task{}, err
+		}
+		template = mergeTask(base, template)
+	}
+
+	template.Overrides = nil
+	tr.resolved[name] = template
+	return template, nil
+}
+
+func mergeTask(base, child models.Task) models.Task {
+	result := child
+	resultValue := reflect.ValueOf(&result).Elem()
+	baseValue := reflect.ValueOf(base)
+	taskType := resultValue.Type()
+
+	for i := 0; i < taskType.NumField(); i++ {
+		fieldName := taskType.Field(i).Name
+		if nonInheritedFields[fieldName] {
+			continue
+		}
+
+		field := resultValue.Field(i)
+		baseField := baseValue.Field(i)
+		mode, set := child.Overrides[fieldName]
+
+		switch {
+		case !set:
+			field.Set(copyValue(baseField))
+		case field.Kind() == reflect.Slice && mode == models.MergeAppend:
+			field.Set(reflect.AppendSlice(copyValue(baseField), field))
+		case field.Kind() == reflect.Map:
+			merged := copyValue(baseField)
+			if merged.IsNil() {
+				merged = reflect.MakeMap(field.Type())
+			}
+			iter := field.MapRange()
+			for iter.Next() {
+				merged.SetMapIndex(iter.Key(), iter.Value())
+			}
+			field.Set(merged)
+		}
+	}
+
+	return result
+}
+
+func copyValue(value reflect.Value) reflect.Value {
+	switch value.Kind() {
+	case reflect.Slice:
+		if value.IsNil() {
+			return reflect.Zero(value.Type())
+		}
+		return reflect.AppendSlice(reflect.MakeSlice(value.Type(), 0, value.Len()), value)
+	case reflect.Map:
+		if value.IsNil() {
+			return reflect.Zero(value.Type())
+		}
+		copied := reflect.MakeMapWithSize(value.Type(), value.Len())
+		iter := value.MapRange()
+		for iter.Next() {
+			copied.SetMapIndex(iter.Key(), iter.Value())
+		}
+		return copied
+	default:
+		return value
+	}
+}
+
+func sortedNames[T any](m map[string]T) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
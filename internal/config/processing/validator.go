@@ -2,10 +2,13 @@ package processing
 
 import (
 	"fmt"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/azuyamat/pace/internal/config/types"
+	"github.com/azuyamat/pace/internal/models"
 )
 
 type Validator struct {
@@ -29,6 +32,9 @@ func (v *Validator) Validate() error {
 	v.validateAliases()
 	v.validateTimeouts()
 	v.validateRetry()
+	v.validateArgs()
+	v.validateServices()
+	v.validateGroups()
 
 	if len(v.errors) > 0 {
 		return v.combineErrors()
@@ -122,12 +128,12 @@ func (v *Validator) hasCyclicDependencies() bool {
 
 func (v *Validator) isCyclic(taskName string, visited, recStack map[string]bool) bool {
 	visited[taskName] = true
-	recStack[taskName] = true
 
 	task, exists := v.config.Tasks[taskName]
 	if !exists {
 		return false
 	}
+	recStack[taskName] = true
 
 	for _, dep := range task.DependsOn {
 		if !visited[dep] {
@@ -189,6 +195,11 @@ func (v *Validator) validateTimeouts() {
 				v.addError(fmt.Errorf("task '%s' has invalid retry_delay format '%s': %v", name, task.RetryDelay, err))
 			}
 		}
+		if task.WatchOptions != nil {
+			if _, err := time.ParseDuration(task.WatchOptions.Debounce); err != nil {
+				v.addError(fmt.Errorf("task '%s' has invalid watch debounce format '%s': %v", name, task.WatchOptions.Debounce, err))
+			}
+		}
 	}
 }
 
@@ -199,3 +210,86 @@ func (v *Validator) validateRetry() {
 		}
 	}
 }
+
+func (v *Validator) validateArgs() {
+	for name, task := range v.config.Tasks {
+		if task.Args == nil {
+			continue
+		}
+		for _, arg := range task.Args.Declared {
+			if !arg.HasDefault {
+				continue
+			}
+			switch arg.Type {
+			case models.ArgTypeInt:
+				if _, err := strconv.Atoi(arg.Default); err != nil {
+					v.addError(fmt.Errorf("task '%s' argument '%s' has non-integer default '%s'", name, arg.Name, arg.Default))
+				}
+			case models.ArgTypeBool:
+				if _, err := strconv.ParseBool(arg.Default); err != nil {
+					v.addError(fmt.Errorf("task '%s' argument '%s' has non-boolean default '%s'", name, arg.Name, arg.Default))
+				}
+			}
+			if len(arg.Choices) > 0 && !slices.Contains(arg.Choices, arg.Default) {
+				v.addError(fmt.Errorf("task '%s' argument '%s' has default '%s' which is not one of %v", name, arg.Name, arg.Default, arg.Choices))
+			}
+		}
+	}
+}
+
+func (v *Validator) validateServices() {
+	for name, task := range v.config.Tasks {
+		if task.Ready == nil {
+			continue
+		}
+		if !task.Service && !v.inGroup(name) {
+			v.addError(fmt.Errorf("task '%s' has a ready check but is neither a service nor in a group", name))
+			continue
+		}
+		ready := task.Ready
+		if ready.Port == 0 && ready.HTTP == "" && ready.Log == "" && ready.Command == "" {
+			v.addError(fmt.Errorf("task '%s' has a ready block without a port, http, log or command check", name))
+		}
+		if ready.Port < 0 || ready.Port > 65535 {
+			v.addError(fmt.Errorf("task '%s' has invalid ready port %d", name, ready.Port))
+		}
+		if _, err := time.ParseDuration(ready.Timeout); err != nil {
+			v.addError(fmt.Errorf("task '%s' has invalid ready timeout format '%s': %v", name, ready.Timeout, err))
+		}
+		if interval, err := time.ParseDuration(ready.Interval); err != nil || interval <= 0 {
+			v.addError(fmt.Errorf("task '%s' has invalid ready interval '%s'", name, ready.Interval))
+		}
+	}
+}
+
+// inGroup reports whether a task is started by "pace up", which runs every
+// group member as a service.
+func (v *Validator) inGroup(taskName string) bool {
+	for _, members := range v.config.Groups {
+		if slices.Contains(members, taskName) {
+			return true
+		}
+	}
+	return false
+}
+
+func (v *Validator) validateGroups() {
+	for name, members := range v.config.Groups {
+		if len(members) == 0 {
+			v.addError(fmt.Errorf("group '%s' has no tasks", name))
+		}
+		for _, member := range members {
+			if _, exists := v.config.Tasks[member]; !exists {
+				v.addError(fmt.Errorf("group '%s' references non-existent task '%s'", name, member))
+			}
+		}
+	}
+
+	for name, task := range v.config.Tasks {
+		switch task.Restart {
+		case "", models.RestartNever, models.RestartOnFailure, models.RestartAlways:
+		default:
+			v.addError(fmt.Errorf("task '%s' has invalid restart policy '%s', expected \"no\", \"on-failure\" or \"always\"", name, task.Restart))
+		}
+	}
+}
@@ -1,25 +1,111 @@
 package types
 
-import "github.com/azuyamat/pace/internal/models"
+import (
+	"strings"
+
+	"github.com/azuyamat/pace/internal/models"
+)
+
+// DynamicVar is a variable whose value is the output of a shell command,
+// evaluated the first time it is referenced during a run.
+type DynamicVar struct {
+	Name    string        `json:"name"`
+	Command string        `json:"command"`
+	Source  models.Source `json:"source"`
+}
+
+// Import is an import statement. The definitions of an import with an Alias
+// are exposed as "<alias>.<name>" instead of being merged as they are.
+type Import struct {
+	Path  string     `json:"path"`
+	Alias string     `json:"alias,omitempty"`
+	Kind  ImportKind `json:"kind,omitempty"`
+	Line  int        `json:"line,omitempty"`
+}
+
+// ImportKind is the kind of file an import reads. Files of other tools
+// have their scripts, targets or aliases exposed as tasks.
+type ImportKind string
+
+const (
+	ImportConfig  ImportKind = ""
+	ImportScripts ImportKind = "scripts"
+	ImportMake    ImportKind = "make"
+	ImportCargo   ImportKind = "cargo"
+)
+
+// ImportKinds lists every kind of import.
+var ImportKinds = []ImportKind{ImportConfig, ImportScripts, ImportMake, ImportCargo}
+
+// Keyword returns the statement importing this kind of file, such as
+// import_scripts.
+func (k ImportKind) Keyword() string {
+	if k == ImportConfig {
+		return "import"
+	}
+	return "import_" + string(k)
+}
+
+// Workspace is declared by the root config of a monorepo. Each member keeps
+// its own config file, and its tasks are addressed as "<member>:<task>".
+type Workspace struct {
+	// Patterns are the globs listed in the workspace block, relative to the
+	// root config.
+	Patterns []string `json:"patterns"`
+	// Members are the directories matched by Patterns that contain a config
+	// file, relative to the root config and slash separated.
+	Members []string `json:"members"`
+}
 
 type Config struct {
-	Tasks       map[string]models.Task
-	Hooks       map[string]models.Hook
-	Globals     map[string]string
-	Constants   map[string]string
-	DefaultTask string
-	Aliases     map[string]string
-	Imports     []string
+	Tasks       map[string]models.Task `json:"tasks"`
+	Templates   map[string]models.Task `json:"templates"`
+	Hooks       map[string]models.Hook `json:"hooks"`
+	Globals     map[string]string      `json:"globals"`
+	Constants   map[string]string      `json:"constants"`
+	DynamicVars map[string]DynamicVar  `json:"dynamic_vars"`
+	DefaultTask string                 `json:"default_task,omitempty"`
+	Aliases     map[string]string      `json:"aliases"`
+	// Groups maps a group name to the tasks started together by "pace up".
+	Groups   map[string][]string `json:"groups"`
+	Imports  []Import            `json:"imports"`
+	EnvFiles []string            `json:"env_files"`
+	// Workspace is nil unless the config declares a workspace block.
+	Workspace *Workspace `json:"workspace,omitempty"`
+	// Dir is the absolute directory of the main config file. Relative paths
+	// of imported files and workspace members are rebased onto it.
+	Dir string `json:"-"`
+	// InvocationDir is the directory pace was started from, before changing
+	// into Dir. It is empty when the config was not loaded by GetConfig.
+	InvocationDir string `json:"-"`
+	// Member is the workspace member pace was started in, whose tasks can
+	// be named without the "<member>:" prefix.
+	Member string `json:"-"`
+	// DotEnv holds the variables loaded from EnvFiles. It is left out of
+	// JSON dumps, since env files often hold secrets.
+	DotEnv map[string]string `json:"-"`
+	// VarSources records where each variable was declared, and UsedVars
+	// the variables referenced while loading, so that unused ones can be
+	// reported.
+	VarSources map[string]models.Source `json:"-"`
+	UsedVars   map[string]bool          `json:"-"`
 }
 
 func NewConfig() *Config {
 	return &Config{
-		Tasks:     make(map[string]models.Task),
-		Hooks:     make(map[string]models.Hook),
-		Globals:   make(map[string]string),
-		Constants: make(map[string]string),
-		Aliases:   make(map[string]string),
-		Imports:   make([]string, 0),
+		Tasks:       make(map[string]models.Task),
+		Templates:   make(map[string]models.Task),
+		Hooks:       make(map[string]models.Hook),
+		Globals:     make(map[string]string),
+		Constants:   make(map[string]string),
+		DynamicVars: make(map[string]DynamicVar),
+		Aliases:     make(map[string]string),
+		Groups:      make(map[string][]string),
+		Imports:     make([]Import, 0),
+		EnvFiles:    make([]string, 0),
+		DotEnv:      make(map[string]string),
+		VarSources:  make(map[string]models.Source),
+		UsedVars:    make(map[string]bool),
 	}
 }
 
@@ -32,10 +118,31 @@ func (cfg *Config) GetTaskOrDefault(name string) (models.Task, bool) {
 	if name == "" && cfg.DefaultTask != "" {
 		name = cfg.DefaultTask
 	}
-	if alias, exists := cfg.Aliases[name]; exists {
-		name = alias
+	return cfg.GetTask(cfg.TaskName(name))
+}
+
+// TaskName returns the task a name given on the command line stands for:
+// a task or alias of Member when there is one, or else the task itself or
+// the target of the alias.
+func (cfg *Config) TaskName(name string) string {
+	if cfg.Member != "" && !strings.Contains(name, ":") {
+		qualified := cfg.Member + ":" + name
+		if _, exists := cfg.Tasks[qualified]; exists {
+			return qualified
+		}
+		if target, exists := cfg.Aliases[qualified]; exists {
+			return target
+		}
+	}
+	if target, exists := cfg.Aliases[name]; exists {
+		return target
 	}
-	return cfg.GetTask(name)
+	return name
+}
+
+func (cfg *Config) GetTemplate(name string) (models.Task, bool) {
+	template, exists := cfg.Templates[name]
+	return template, exists
 }
 
 func (cfg *Config) GetHook(name string) (models.Hook, bool) {
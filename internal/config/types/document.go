@@ -0,0 +1,354 @@
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/azuyamat/pace/internal/models"
+	"gopkg.in/yaml.v3"
+)
+
+// Format is the syntax of a config file.
+type Format string
+
+const (
+	FormatPace Format = "pace"
+	FormatYAML Format = "yaml"
+	FormatTOML Format = "toml"
+	FormatJSON Format = "json"
+)
+
+// FormatOf returns the format of a config file from its extension. Files
+// with any other extension are in the pace syntax.
+func FormatOf(file string) Format {
+	switch strings.ToLower(path.Ext(file)) {
+	case ".yaml", ".yml":
+		return FormatYAML
+	case ".toml":
+		return FormatTOML
+	case ".json":
+		return FormatJSON
+	default:
+		return FormatPace
+	}
+}
+
+// ParseFormat returns the format with the given name.
+func ParseFormat(name string) (Format, error) {
+	switch format := Format(strings.ToLower(name)); format {
+	case FormatPace, FormatYAML, FormatTOML, FormatJSON:
+		return format, nil
+	case "yml":
+		return FormatYAML, nil
+	default:
+		return "", fmt.Errorf("unknown format '%s', expected pace, yaml, toml or json", name)
+	}
+}
+
+// Document is the layout of a config file written in YAML, TOML or JSON.
+// Its keys are the keywords and property names of the pace syntax.
+type Document struct {
+	Default   string                  `json:"default,omitempty"`
+	Imports   []DocumentImport        `json:"imports,omitempty"`
+	EnvFile   []string                `json:"env_file,omitempty"`
+	Vars      map[string]any          `json:"vars,omitempty"`
+	Globals   map[string]string       `json:"globals,omitempty"`
+	Aliases   map[string]string       `json:"aliases,omitempty"`
+	Groups    map[string][]string     `json:"groups,omitempty"`
+	Workspace *DocumentWorkspace      `json:"workspace,omitempty"`
+	Templates map[string]DocumentTask `json:"templates,omitempty"`
+	Tasks     map[string]DocumentTask `json:"tasks,omitempty"`
+	Hooks     map[string]DocumentHook `json:"hooks,omitempty"`
+}
+
+type DocumentImport struct {
+	Path string     `json:"path"`
+	As   string     `json:"as,omitempty"`
+	Kind ImportKind `json:"kind,omitempty"`
+}
+
+// DocumentVar is a dynamic variable, the document form of sh("command").
+type DocumentVar struct {
+	Sh string `json:"sh"`
+}
+
+type DocumentWorkspace struct {
+	Members []string `json:"members"`
+}
+
+// DocumentTask is a task or template. Its list properties hold either a
+// []string or, for a task written with "+=", a DocumentAppend.
+type DocumentTask struct {
+	Alias           string             `json:"alias,omitempty"`
+	Extends         string             `json:"extends,omitempty"`
+	Command         string             `json:"command,omitempty"`
+	Description     string             `json:"description,omitempty"`
+	WorkingDir      string             `json:"working_dir,omitempty"`
+	Inputs          any                `json:"inputs,omitempty"`
+	Outputs         any                `json:"outputs,omitempty"`
+	DependsOn       any                `json:"depends-on,omitempty"`
+	Requires        any                `json:"requires,omitempty"`
+	Triggers        any                `json:"triggers,omitempty"`
+	OnSuccess       any                `json:"on_success,omitempty"`
+	OnFailure       any                `json:"on_failure,omitempty"`
+	Env             map[string]string  `json:"env,omitempty"`
+	EnvFile         any                `json:"env_file,omitempty"`
+	Cache           bool               `json:"cache,omitempty"`
+	Watch           any                `json:"watch,omitempty"`
+	WatchInputs     any                `json:"watch_inputs,omitempty"`
+	Service         bool               `json:"service,omitempty"`
+	Ready           *models.ReadyCheck `json:"ready,omitempty"`
+	Restart         string             `json:"restart,omitempty"`
+	Parallel        bool               `json:"parallel,omitempty"`
+	Silent          bool               `json:"silent,omitempty"`
+	ContinueOnError bool               `json:"continue_on_error,omitempty"`
+	Timeout         string             `json:"timeout,omitempty"`
+	Retry           int                `json:"retry,omitempty"`
+	RetryDelay      string             `json:"retry_delay,omitempty"`
+	When            string             `json:"when,omitempty"`
+	Args            *DocumentArgs      `json:"args,omitempty"`
+	Arg             []DocumentArg      `json:"arg,omitempty"`
+}
+
+// DocumentAppend is the document form of "+=", which appends to a list
+// inherited from the template instead of replacing it.
+type DocumentAppend struct {
+	Append []string `json:"append"`
+}
+
+// DocumentWatch is the object form of "watch". Enabled is "watch true".
+type DocumentWatch struct {
+	Enabled bool `json:"enabled,omitempty"`
+	models.WatchOptions
+}
+
+type DocumentArgs struct {
+	Required []string `json:"required,omitempty"`
+	Optional []string `json:"optional,omitempty"`
+}
+
+type DocumentArg struct {
+	Name    string         `json:"name"`
+	Type    models.ArgType `json:"type"`
+	Default *string        `json:"default,omitempty"`
+	Choices []string       `json:"choices,omitempty"`
+}
+
+type DocumentHook struct {
+	Command     string            `json:"command,omitempty"`
+	Description string            `json:"description,omitempty"`
+	WorkingDir  string            `json:"working_dir,omitempty"`
+	Env         map[string]string `json:"env,omitempty"`
+	EnvFile     []string          `json:"env_file,omitempty"`
+}
+
+// Document returns the config in the layout of YAML, TOML and JSON files.
+func (c *Config) Document() Document {
+	doc := Document{
+		Default: c.DefaultTask,
+		EnvFile: c.EnvFiles,
+		Globals: c.Globals,
+		Groups:  c.Groups,
+	}
+
+	for _, imp := range c.Imports {
+		doc.Imports = append(doc.Imports, DocumentImport{Path: imp.Path, As: imp.Alias, Kind: imp.Kind})
+	}
+
+	if len(c.Constants)+len(c.DynamicVars) > 0 {
+		doc.Vars = make(map[string]any, len(c.Constants)+len(c.DynamicVars))
+		for name, value := range c.Constants {
+			doc.Vars[name] = value
+		}
+		for name, dynamicVar := range c.DynamicVars {
+			doc.Vars[name] = DocumentVar{Sh: dynamicVar.Command}
+		}
+	}
+
+	if aliases := c.standaloneAliases(); len(aliases) > 0 {
+		doc.Aliases = aliases
+	}
+
+	if c.Workspace != nil {
+		doc.Workspace = &DocumentWorkspace{Members: c.Workspace.Patterns}
+	}
+
+	if len(c.Templates) > 0 {
+		doc.Templates = make(map[string]DocumentTask, len(c.Templates))
+		for name, template := range c.Templates {
+			doc.Templates[name] = documentTask(template)
+		}
+	}
+	if len(c.Tasks) > 0 {
+		doc.Tasks = make(map[string]DocumentTask, len(c.Tasks))
+		for name, task := range c.Tasks {
+			doc.Tasks[name] = documentTask(task)
+		}
+	}
+	if len(c.Hooks) > 0 {
+		doc.Hooks = make(map[string]DocumentHook, len(c.Hooks))
+		for name, hook := range c.Hooks {
+			doc.Hooks[name] = DocumentHook{
+				Command:     hook.Command,
+				Description: hook.Description,
+				WorkingDir:  hook.WorkingDir,
+				Env:         hook.Env,
+				EnvFile:     hook.EnvFiles,
+			}
+		}
+	}
+
+	return doc
+}
+
+func documentTask(task models.Task) DocumentTask {
+	doc := DocumentTask{
+		Alias:           task.Alias,
+		Extends:         task.Extends,
+		Command:         task.Command,
+		Description:     task.Description,
+		WorkingDir:      task.WorkingDir,
+		Inputs:          documentList(task, "Inputs", task.Inputs),
+		Outputs:         documentList(task, "Outputs", task.Outputs),
+		DependsOn:       documentList(task, "DependsOn", task.DependsOn),
+		Requires:        documentList(task, "Requires", task.Requires),
+		Triggers:        documentList(task, "Triggers", task.Triggers),
+		OnSuccess:       documentList(task, "OnSuccess", task.OnSuccess),
+		OnFailure:       documentList(task, "OnFailure", task.OnFailure),
+		Env:             task.Env,
+		EnvFile:         documentList(task, "EnvFiles", task.EnvFiles),
+		Cache:           task.Cache,
+		WatchInputs:     documentList(task, "WatchInputs", task.WatchInputs),
+		Service:         task.Service,
+		Ready:           task.Ready,
+		Restart:         string(task.Restart),
+		Parallel:        task.Parallel,
+		Silent:          task.Silent,
+		ContinueOnError: task.ContinueOnError,
+		Timeout:         task.Timeout,
+		Retry:           task.Retry,
+		RetryDelay:      task.RetryDelay,
+		When:            task.When,
+	}
+
+	if task.WatchOptions != nil {
+		doc.Watch = DocumentWatch{Enabled: task.Watch, WatchOptions: *task.WatchOptions}
+	} else if task.Watch {
+		doc.Watch = true
+	}
+
+	if task.Args != nil {
+		required := undeclaredArgs(task.Args, task.Args.Required)
+		optional := undeclaredArgs(task.Args, task.Args.Optional)
+		if len(required) > 0 || len(optional) > 0 {
+			doc.Args = &DocumentArgs{Required: required, Optional: optional}
+		}
+		for _, arg := range task.Args.Declared {
+			declared := DocumentArg{Name: arg.Name, Type: arg.Type, Choices: arg.Choices}
+			if arg.HasDefault {
+				value := arg.Default
+				declared.Default = &value
+			}
+			doc.Arg = append(doc.Arg, declared)
+		}
+	}
+
+	return doc
+}
+
+// documentList returns a list property of the task, nil when it is empty so
+// that it is left out.
+func documentList(task models.Task, field string, items []string) any {
+	if len(items) == 0 {
+		return nil
+	}
+	if task.Overrides[field] == models.MergeAppend {
+		return DocumentAppend{Append: items}
+	}
+	return items
+}
+
+// Encode writes the config in the given format. YAML and TOML are produced
+// from the JSON form of Document, so that every format uses the same keys.
+func (c *Config) Encode(format Format) ([]byte, error) {
+	if format == FormatPace {
+		return []byte(c.String()), nil
+	}
+
+	data, err := json.MarshalIndent(c.Document(), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	switch format {
+	case FormatJSON:
+		return append(data, '\n'), nil
+
+	case FormatYAML:
+		// JSON is valid YAML; decoding it into a node keeps the key order,
+		// and clearing the styles turns it into block YAML.
+		var node yaml.Node
+		if err := yaml.Unmarshal(data, &node); err != nil {
+			return nil, err
+		}
+		clearStyle(&node)
+		var buf bytes.Buffer
+		encoder := yaml.NewEncoder(&buf)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(&node); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+
+	case FormatTOML:
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		var values map[string]any
+		if err := decoder.Decode(&values); err != nil {
+			return nil, err
+		}
+		var buf bytes.Buffer
+		if err := toml.NewEncoder(&buf).Encode(tomlValues(values)); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+
+	default:
+		return nil, fmt.Errorf("unknown format '%s'", format)
+	}
+}
+
+func clearStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		clearStyle(child)
+	}
+}
+
+// tomlValues converts the numbers of decoded JSON to integers, which is
+// the only kind of number in Document.
+func tomlValues(value any) any {
+	switch value := value.(type) {
+	case map[string]any:
+		for key, item := range value {
+			value[key] = tomlValues(item)
+		}
+		return value
+	case []any:
+		for i, item := range value {
+			value[i] = tomlValues(item)
+		}
+		return value
+	case json.Number:
+		if n, err := value.Int64(); err == nil {
+			return n
+		}
+		return value.String()
+	default:
+		return value
+	}
+}
@@ -26,8 +26,19 @@ func (c *Config) String() string {
 			builder.WriteString("\n")
 		}
 		for _, imp := range c.Imports {
-			builder.WriteString(fmt.Sprintf("import \"%s\"\n", imp))
+			if imp.Alias != "" {
+				builder.WriteString(fmt.Sprintf("%s \"%s\" as %s\n", imp.Kind.Keyword(), imp.Path, imp.Alias))
+			} else {
+				builder.WriteString(fmt.Sprintf("%s \"%s\"\n", imp.Kind.Keyword(), imp.Path))
+			}
+		}
+	}
+
+	if len(c.EnvFiles) > 0 {
+		if builder.Len() > 0 {
+			builder.WriteString("\n")
 		}
+		builder.WriteString(fmt.Sprintf("env_file %s\n", formatStringSlice(c.EnvFiles)))
 	}
 
 	if len(c.Constants) > 0 {
@@ -40,6 +51,16 @@ func (c *Config) String() string {
 		}
 	}
 
+	if len(c.DynamicVars) > 0 {
+		if builder.Len() > 0 {
+			builder.WriteString("\n")
+		}
+		keys := sortedKeys(c.DynamicVars)
+		for _, key := range keys {
+			builder.WriteString(fmt.Sprintf("var %s = $(%s)\n", key, c.DynamicVars[key].Command))
+		}
+	}
+
 	if len(c.Globals) > 0 {
 		if builder.Len() > 0 {
 			builder.WriteString("\n")
@@ -52,13 +73,40 @@ func (c *Config) String() string {
 		builder.WriteString("}\n")
 	}
 
-	if len(c.Aliases) > 0 {
+	if aliases := c.standaloneAliases(); len(aliases) > 0 {
+		if builder.Len() > 0 {
+			builder.WriteString("\n")
+		}
+		keys := sortedKeys(aliases)
+		for _, key := range keys {
+			builder.WriteString(fmt.Sprintf("alias %s %s\n", key, aliases[key]))
+		}
+	}
+
+	if len(c.Groups) > 0 {
 		if builder.Len() > 0 {
 			builder.WriteString("\n")
 		}
-		keys := sortedKeys(c.Aliases)
+		keys := sortedKeys(c.Groups)
 		for _, key := range keys {
-			builder.WriteString(fmt.Sprintf("alias %s %s\n", key, c.Aliases[key]))
+			builder.WriteString(fmt.Sprintf("group %s [%s]\n", key, strings.Join(c.Groups[key], ", ")))
+		}
+	}
+
+	if c.Workspace != nil {
+		if builder.Len() > 0 {
+			builder.WriteString("\n")
+		}
+		builder.WriteString(fmt.Sprintf("workspace {\n    members %s\n}\n", formatStringSlice(c.Workspace.Patterns)))
+	}
+
+	if len(c.Templates) > 0 {
+		keys := sortedKeys(c.Templates)
+		for _, name := range keys {
+			if builder.Len() > 0 {
+				builder.WriteString("\n")
+			}
+			builder.WriteString(templateString(c.Templates[name]))
 		}
 	}
 
@@ -85,55 +133,87 @@ func (c *Config) String() string {
 	return builder.String()
 }
 
+// standaloneAliases returns the aliases not declared on their task, as in
+// task build [b] { ... }.
+func (c *Config) standaloneAliases() map[string]string {
+	aliases := make(map[string]string, len(c.Aliases))
+	for alias, target := range c.Aliases {
+		if task, exists := c.Tasks[target]; exists && task.Alias == alias {
+			continue
+		}
+		aliases[alias] = target
+	}
+	return aliases
+}
+
 func taskString(task models.Task) string {
+	return taskBlockString("task", task)
+}
+
+func templateString(template models.Task) string {
+	return taskBlockString("template", template)
+}
+
+func taskBlockString(keyword string, task models.Task) string {
 	var builder strings.Builder
 
-	builder.WriteString(fmt.Sprintf("task %s {\n", task.Name))
+	builder.WriteString(fmt.Sprintf("%s %s", keyword, task.Name))
+	if task.Alias != "" {
+		builder.WriteString(fmt.Sprintf(" [%s]", task.Alias))
+	}
+	if task.Extends != "" {
+		builder.WriteString(fmt.Sprintf(" extends %s", task.Extends))
+	}
+	builder.WriteString(" {\n")
 
 	if task.Command != "" {
-		builder.WriteString(fmt.Sprintf("    command \"%s\"\n", task.Command))
+		builder.WriteString(fmt.Sprintf("    command %s\n", quoteString(task.Command)))
 	}
 
 	if task.Description != "" {
-		builder.WriteString(fmt.Sprintf("    description \"%s\"\n", task.Description))
+		builder.WriteString(fmt.Sprintf("    description %s\n", quoteString(task.Description)))
 	}
 
 	if task.WorkingDir != "" {
-		builder.WriteString(fmt.Sprintf("    working_dir \"%s\"\n", task.WorkingDir))
+		builder.WriteString(fmt.Sprintf("    working_dir %s\n", quoteString(task.WorkingDir)))
 	}
 
 	if len(task.Inputs) > 0 {
-		builder.WriteString(fmt.Sprintf("    inputs %s\n", formatStringSlice(task.Inputs)))
+		builder.WriteString(listPropertyString(task, "inputs", "Inputs", task.Inputs))
 	}
 
 	if len(task.Outputs) > 0 {
-		builder.WriteString(fmt.Sprintf("    outputs %s\n", formatStringSlice(task.Outputs)))
+		builder.WriteString(listPropertyString(task, "outputs", "Outputs", task.Outputs))
 	}
 
 	if len(task.DependsOn) > 0 {
-		builder.WriteString(fmt.Sprintf("    depends-on %s\n", formatStringSlice(task.DependsOn)))
+		builder.WriteString(listPropertyString(task, "depends-on", "DependsOn", task.DependsOn))
 	}
 
 	if len(task.Requires) > 0 {
-		builder.WriteString(fmt.Sprintf("    requires %s\n", formatStringSlice(task.Requires)))
+		builder.WriteString(listPropertyString(task, "requires", "Requires", task.Requires))
 	}
 
 	if len(task.Triggers) > 0 {
-		builder.WriteString(fmt.Sprintf("    triggers %s\n", formatStringSlice(task.Triggers)))
+		builder.WriteString(listPropertyString(task, "triggers", "Triggers", task.Triggers))
 	}
 
 	if len(task.OnSuccess) > 0 {
-		builder.WriteString(fmt.Sprintf("    on_success %s\n", formatStringSlice(task.OnSuccess)))
+		builder.WriteString(listPropertyString(task, "on_success", "OnSuccess", task.OnSuccess))
 	}
 
 	if len(task.OnFailure) > 0 {
-		builder.WriteString(fmt.Sprintf("    on_failure %s\n", formatStringSlice(task.OnFailure)))
+		builder.WriteString(listPropertyString(task, "on_failure", "OnFailure", task.OnFailure))
 	}
 
 	if len(task.Env) > 0 {
 		builder.WriteString(fmt.Sprintf("    env %s\n", formatStringMap(task.Env)))
 	}
 
+	if len(task.EnvFiles) > 0 {
+		builder.WriteString(listPropertyString(task, "env_file", "EnvFiles", task.EnvFiles))
+	}
+
 	if task.Cache {
 		builder.WriteString("    cache true\n")
 	}
@@ -142,6 +222,26 @@ func taskString(task models.Task) string {
 		builder.WriteString("    watch true\n")
 	}
 
+	if task.WatchOptions != nil {
+		builder.WriteString(watchOptionsString(*task.WatchOptions))
+	}
+
+	if len(task.WatchInputs) > 0 {
+		builder.WriteString(listPropertyString(task, "watch_inputs", "WatchInputs", task.WatchInputs))
+	}
+
+	if task.Service {
+		builder.WriteString("    service true\n")
+	}
+
+	if task.Ready != nil {
+		builder.WriteString(readyCheckString(*task.Ready))
+	}
+
+	if task.Restart != "" {
+		builder.WriteString(fmt.Sprintf("    restart \"%s\"\n", task.Restart))
+	}
+
 	if task.Parallel {
 		builder.WriteString("    parallel true\n")
 	}
@@ -167,15 +267,24 @@ func taskString(task models.Task) string {
 	}
 
 	if task.When != "" {
-		builder.WriteString(fmt.Sprintf("    when \"%s\"\n", task.When))
+		builder.WriteString(fmt.Sprintf("    when %s\n", quoteString(task.When)))
 	}
 
 	if task.Args != nil {
-		if len(task.Args.Required) > 0 {
-			builder.WriteString(fmt.Sprintf("    args.required %s\n", formatStringSlice(task.Args.Required)))
+		required := undeclaredArgs(task.Args, task.Args.Required)
+		optional := undeclaredArgs(task.Args, task.Args.Optional)
+		if len(required) > 0 || len(optional) > 0 {
+			builder.WriteString("    args {\n")
+			if len(required) > 0 {
+				builder.WriteString(fmt.Sprintf("        required %s\n", formatStringSlice(required)))
+			}
+			if len(optional) > 0 {
+				builder.WriteString(fmt.Sprintf("        optional %s\n", formatStringSlice(optional)))
+			}
+			builder.WriteString("    }\n")
 		}
-		if len(task.Args.Optional) > 0 {
-			builder.WriteString(fmt.Sprintf("    args.optional %s\n", formatStringSlice(task.Args.Optional)))
+		for _, arg := range task.Args.Declared {
+			builder.WriteString(fmt.Sprintf("    %s\n", argString(arg)))
 		}
 	}
 
@@ -183,27 +292,62 @@ func taskString(task models.Task) string {
 	return builder.String()
 }
 
+// listPropertyString writes a list property, appending to the value
+// inherited from the template when the task was written with "+=".
+func listPropertyString(task models.Task, name, field string, items []string) string {
+	operator := " "
+	if task.Overrides[field] == models.MergeAppend {
+		operator = " += "
+	}
+	return fmt.Sprintf("    %s%s%s\n", name, operator, formatStringSlice(items))
+}
+
+func argString(arg models.TaskArg) string {
+	parts := []string{"arg", arg.Name, string(arg.Type)}
+	if arg.HasDefault {
+		parts = append(parts, "default", fmt.Sprintf("\"%s\"", arg.Default))
+	}
+	if len(arg.Choices) > 0 {
+		parts = append(parts, "choices", formatStringSlice(arg.Choices))
+	}
+	return strings.Join(parts, " ")
+}
+
+func undeclaredArgs(args *models.TaskArgs, names []string) []string {
+	result := make([]string, 0, len(names))
+	for _, name := range names {
+		if _, declared := args.Lookup(name); !declared {
+			result = append(result, name)
+		}
+	}
+	return result
+}
+
 func hookString(hook models.Hook) string {
 	var builder strings.Builder
 
 	builder.WriteString(fmt.Sprintf("hook %s {\n", hook.Name))
 
 	if hook.Command != "" {
-		builder.WriteString(fmt.Sprintf("    command \"%s\"\n", hook.Command))
+		builder.WriteString(fmt.Sprintf("    command %s\n", quoteString(hook.Command)))
 	}
 
 	if hook.Description != "" {
-		builder.WriteString(fmt.Sprintf("    description \"%s\"\n", hook.Description))
+		builder.WriteString(fmt.Sprintf("    description %s\n", quoteString(hook.Description)))
 	}
 
 	if hook.WorkingDir != "" {
-		builder.WriteString(fmt.Sprintf("    working_dir \"%s\"\n", hook.WorkingDir))
+		builder.WriteString(fmt.Sprintf("    working_dir %s\n", quoteString(hook.WorkingDir)))
 	}
 
 	if len(hook.Env) > 0 {
 		builder.WriteString(fmt.Sprintf("    env %s\n", formatStringMap(hook.Env)))
 	}
 
+	if len(hook.EnvFiles) > 0 {
+		builder.WriteString(fmt.Sprintf("    env_file %s\n", formatStringSlice(hook.EnvFiles)))
+	}
+
 	builder.WriteString("}\n")
 	return builder.String()
 }
@@ -214,7 +358,7 @@ func formatStringSlice(items []string) string {
 	}
 	quoted := make([]string, len(items))
 	for i, item := range items {
-		quoted[i] = fmt.Sprintf("\"%s\"", escapeString(item))
+		quoted[i] = fmt.Sprintf("\"%s\"", item)
 	}
 	return "[" + strings.Join(quoted, ", ") + "]"
 }
@@ -227,18 +371,20 @@ func formatStringMap(m map[string]string) string {
 	builder.WriteString("{\n")
 	keys := sortedKeys(m)
 	for _, key := range keys {
-		builder.WriteString(fmt.Sprintf("        %s = %s\n", key, escapeString(m[key])))
+		builder.WriteString(fmt.Sprintf("        \"%s\" = \"%s\"\n", key, m[key]))
 	}
 	builder.WriteString("    }")
 	return builder.String()
 }
 
-func escapeString(s string) string {
-	s = strings.ReplaceAll(s, "\\", "\\\\")
-	s = strings.ReplaceAll(s, "\"", "\\\"")
-	s = strings.ReplaceAll(s, "\n", "\\n")
-	s = strings.ReplaceAll(s, "\t", "\\t")
-	return s
+// quoteString quotes a property value. Strings are read without escape
+// sequences, so values containing quotes or newlines are written as
+// """multiline""" strings.
+func quoteString(s string) string {
+	if strings.ContainsAny(s, "\"\n") && !strings.Contains(s, `"""`) {
+		return `"""` + s + `"""`
+	}
+	return `"` + s + `"`
 }
 
 func sortedKeys[T any](m map[string]T) []string {
@@ -249,3 +395,36 @@ func sortedKeys[T any](m map[string]T) []string {
 	sort.Strings(keys)
 	return keys
 }
+
+func watchOptionsString(options models.WatchOptions) string {
+	var builder strings.Builder
+	builder.WriteString("    watch {\n")
+	builder.WriteString(fmt.Sprintf("        debounce \"%s\"\n", options.Debounce))
+	builder.WriteString(fmt.Sprintf("        mode \"%s\"\n", options.Mode))
+	builder.WriteString(fmt.Sprintf("        clear %t\n", options.Clear))
+	builder.WriteString(fmt.Sprintf("        run_on_start %t\n", options.RunOnStart))
+	builder.WriteString(fmt.Sprintf("        interactive %t\n", options.Interactive))
+	builder.WriteString("    }\n")
+	return builder.String()
+}
+
+func readyCheckString(ready models.ReadyCheck) string {
+	var builder strings.Builder
+	builder.WriteString("    ready {\n")
+	if ready.Port != 0 {
+		builder.WriteString(fmt.Sprintf("        port %d\n", ready.Port))
+	}
+	if ready.HTTP != "" {
+		builder.WriteString(fmt.Sprintf("        http %s\n", quoteString(ready.HTTP)))
+	}
+	if ready.Log != "" {
+		builder.WriteString(fmt.Sprintf("        log %s\n", quoteString(ready.Log)))
+	}
+	if ready.Command != "" {
+		builder.WriteString(fmt.Sprintf("        command %s\n", quoteString(ready.Command)))
+	}
+	builder.WriteString(fmt.Sprintf("        timeout \"%s\"\n", ready.Timeout))
+	builder.WriteString(fmt.Sprintf("        interval \"%s\"\n", ready.Interval))
+	builder.WriteString("    }\n")
+	return builder.String()
+}
@@ -1,41 +1,170 @@
 package models
 
+import "fmt"
+
+// Source is the place in a configuration file where a task, template or hook
+// was defined.
+type Source struct {
+	File string `json:"file,omitempty"`
+	Line int    `json:"line,omitempty"`
+}
+
+// String returns "file:line", or only the file for formats without line
+// numbers such as YAML.
+func (s Source) String() string {
+	if s.File == "" {
+		return fmt.Sprintf("line %d", s.Line)
+	}
+	if s.Line == 0 {
+		return s.File
+	}
+	return fmt.Sprintf("%s:%d", s.File, s.Line)
+}
+
 type Hook struct {
-	Name        string
-	Command     string
-	Env         map[string]string
-	WorkingDir  string
-	Description string
+	Name        string            `json:"name"`
+	Command     string            `json:"command"`
+	Env         map[string]string `json:"env,omitempty"`
+	EnvFiles    []string          `json:"env_files,omitempty"`
+	WorkingDir  string            `json:"working_dir,omitempty"`
+	Description string            `json:"description,omitempty"`
+	Source      Source            `json:"source"`
+}
+
+// MergeMode describes how an explicitly set task field combines with the
+// value inherited from the template named in Task.Extends.
+type MergeMode int
+
+const (
+	MergeReplace MergeMode = iota
+	MergeAppend
+)
+
+type ArgType string
+
+const (
+	ArgTypeString ArgType = "string"
+	ArgTypeInt    ArgType = "int"
+	ArgTypeBool   ArgType = "bool"
+)
+
+// TaskArg is a typed argument declared with the "arg" property.
+type TaskArg struct {
+	Name       string   `json:"name"`
+	Type       ArgType  `json:"type"`
+	Default    string   `json:"default,omitempty"`
+	HasDefault bool     `json:"has_default"`
+	Choices    []string `json:"choices,omitempty"`
 }
 
 type TaskArgs struct {
-	Required []string
-	Optional []string
+	Required []string  `json:"required"`
+	Optional []string  `json:"optional"`
+	Declared []TaskArg `json:"declared,omitempty"`
+}
+
+// Lookup returns the typed declaration for name, if there is one.
+func (a *TaskArgs) Lookup(name string) (TaskArg, bool) {
+	for _, arg := range a.Declared {
+		if arg.Name == name {
+			return arg, true
+		}
+	}
+	return TaskArg{}, false
 }
 
+type WatchMode string
+
+const (
+	WatchModeRestart            WatchMode = "restart"
+	WatchModeQueue              WatchMode = "queue"
+	WatchModeIgnoreWhileRunning WatchMode = "ignore-while-running"
+)
+
+// WatchOptions controls how a task is re-run in watch mode.
+type WatchOptions struct {
+	Debounce   string    `json:"debounce"`
+	Mode       WatchMode `json:"mode"`
+	Clear      bool      `json:"clear"`
+	RunOnStart bool      `json:"run_on_start"`
+	// Interactive enables keyboard controls. Tasks that read stdin
+	// themselves turn it off.
+	Interactive bool `json:"interactive"`
+}
+
+// DefaultWatchOptions returns the options used by tasks without a watch block.
+func DefaultWatchOptions() WatchOptions {
+	return WatchOptions{
+		Debounce:    "500ms",
+		Mode:        WatchModeRestart,
+		RunOnStart:  true,
+		Interactive: true,
+	}
+}
+
+// ReadyCheck tells when a service task is ready for its dependents. Every
+// configured probe must succeed before the timeout expires.
+type ReadyCheck struct {
+	Port     int    `json:"port,omitempty"`
+	HTTP     string `json:"http,omitempty"`
+	Log      string `json:"log,omitempty"`
+	Command  string `json:"command,omitempty"`
+	Timeout  string `json:"timeout"`
+	Interval string `json:"interval"`
+}
+
+// DefaultReadyCheck returns the timeout and interval used by ready blocks
+// that do not set them.
+func DefaultReadyCheck() ReadyCheck {
+	return ReadyCheck{
+		Timeout:  "30s",
+		Interval: "250ms",
+	}
+}
+
+type RestartPolicy string
+
+const (
+	RestartNever     RestartPolicy = "no"
+	RestartOnFailure RestartPolicy = "on-failure"
+	RestartAlways    RestartPolicy = "always"
+)
+
 type Task struct {
-	Name            string
-	Alias           string
-	Command         string
-	Inputs          []string
-	Outputs         []string
-	DependsOn       []string
-	Env             map[string]string
-	Cache           bool
-	WorkingDir      string
-	Requires        []string
-	Triggers        []string
-	OnSuccess       []string
-	OnFailure       []string
-	Description     string
-	Watch           bool
-	Parallel        bool
-	Silent          bool
-	ContinueOnError bool
-	Timeout         string
-	Retry           int
-	RetryDelay      string
-	Args            *TaskArgs
-	ExtraArgs       []string
-	When            string
+	Name            string            `json:"name"`
+	Alias           string            `json:"alias,omitempty"`
+	Command         string            `json:"command"`
+	Inputs          []string          `json:"inputs,omitempty"`
+	Outputs         []string          `json:"outputs,omitempty"`
+	DependsOn       []string          `json:"depends_on,omitempty"`
+	Env             map[string]string `json:"env,omitempty"`
+	EnvFiles        []string          `json:"env_files,omitempty"`
+	Cache           bool              `json:"cache,omitempty"`
+	WorkingDir      string            `json:"working_dir,omitempty"`
+	Requires        []string          `json:"requires,omitempty"`
+	Triggers        []string          `json:"triggers,omitempty"`
+	OnSuccess       []string          `json:"on_success,omitempty"`
+	OnFailure       []string          `json:"on_failure,omitempty"`
+	Description     string            `json:"description,omitempty"`
+	Watch           bool              `json:"watch,omitempty"`
+	WatchOptions    *WatchOptions     `json:"watch_options,omitempty"`
+	WatchInputs     []string          `json:"watch_inputs,omitempty"`
+	Service         bool              `json:"service,omitempty"`
+	Ready           *ReadyCheck       `json:"ready,omitempty"`
+	Restart         RestartPolicy     `json:"restart,omitempty"`
+	Parallel        bool              `json:"parallel,omitempty"`
+	Silent          bool              `json:"silent,omitempty"`
+	ContinueOnError bool              `json:"continue_on_error,omitempty"`
+	Timeout         string            `json:"timeout,omitempty"`
+	Retry           int               `json:"retry,omitempty"`
+	RetryDelay      string            `json:"retry_delay,omitempty"`
+	Args            *TaskArgs         `json:"args,omitempty"`
+	ExtraArgs       []string          `json:"-"`
+	ArgValues       map[string]string `json:"-"`
+	When            string            `json:"when,omitempty"`
+	Extends         string            `json:"extends,omitempty"`
+	Source          Source            `json:"source"`
+	// Overrides holds the fields set in the task body keyed by struct field
+	// name. It is cleared once the template has been merged in.
+	Overrides map[string]MergeMode `json:"-"`
 }
@@ -0,0 +1,202 @@
+package runner
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/azuyamat/pace/internal/models"
+)
+
+// argSpecs returns the declarations for every argument of the task in
+// positional order: typed arguments in the order they are declared, then
+// the names listed only in an args block, which are untyped strings.
+func argSpecs(args *models.TaskArgs) []models.TaskArg {
+	specs := slices.Clone(args.Declared)
+	for _, names := range [][]string{args.Required, args.Optional} {
+		for _, name := range names {
+			if _, declared := args.Lookup(name); !declared {
+				specs = append(specs, models.TaskArg{Name: name, Type: models.ArgTypeString})
+			}
+		}
+	}
+	return specs
+}
+
+// parseTaskArgs splits the command line arguments of a task into positional
+// values and named values. Named values may be given as --name=value,
+// --name value or, for booleans, a bare --name. Positional values fill the
+// declared arguments that were not named, in declaration order.
+func parseTaskArgs(task *models.Task, extraArgs []string) ([]string, map[string]string, error) {
+	specs := argSpecs(task.Args)
+	byName := make(map[string]models.TaskArg, len(specs))
+	for _, spec := range specs {
+		byName[spec.Name] = spec
+	}
+
+	values := make(map[string]string)
+	positional := make([]string, 0, len(extraArgs))
+
+	for i := 0; i < len(extraArgs); i++ {
+		arg := extraArgs[i]
+
+		if arg == "--" {
+			positional = append(positional, extraArgs[i+1:]...)
+			break
+		}
+
+		if !strings.HasPrefix(arg, "--") || len(arg) == 2 {
+			positional = append(positional, arg)
+			continue
+		}
+
+		name, value, hasValue := strings.Cut(arg[2:], "=")
+		spec, ok := byName[name]
+		if !ok {
+			return nil, nil, fmt.Errorf("task %q has no argument named %q\n%s", task.Name, name, TaskUsage(*task))
+		}
+		if !hasValue {
+			if spec.Type == models.ArgTypeBool {
+				value = "true"
+			} else if i+1 < len(extraArgs) {
+				i++
+				value = extraArgs[i]
+			} else {
+				return nil, nil, fmt.Errorf("argument --%s of task %q requires a value", name, task.Name)
+			}
+		}
+		values[name] = value
+	}
+
+	next := 0
+	for _, spec := range specs {
+		if _, set := values[spec.Name]; set {
+			continue
+		}
+		if next < len(positional) {
+			values[spec.Name] = positional[next]
+			next++
+		}
+	}
+
+	if next < len(positional) {
+		// Every argument not given by name took a positional value.
+		if named := len(specs) - next; named > 0 {
+			return nil, nil, fmt.Errorf("task %q has %d argument(s) left after the %d given by name but got %d positional value(s)\n%s",
+				task.Name, next, named, len(positional), TaskUsage(*task))
+		}
+		return nil, nil, fmt.Errorf("task %q expects at most %d argument(s) but got %d\n%s",
+			task.Name, len(specs), len(positional), TaskUsage(*task))
+	}
+
+	missing := make([]string, 0)
+	for _, spec := range specs {
+		value, set := values[spec.Name]
+		if !set {
+			switch {
+			case spec.HasDefault:
+				values[spec.Name] = spec.Default
+			case spec.Type == models.ArgTypeBool:
+				values[spec.Name] = "false"
+			case slices.Contains(task.Args.Required, spec.Name):
+				missing = append(missing, spec.Name)
+			default:
+				values[spec.Name] = ""
+			}
+			continue
+		}
+
+		normalized, err := checkArgValue(spec, value)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid value for argument %q of task %q: %v", spec.Name, task.Name, err)
+		}
+		values[spec.Name] = normalized
+	}
+
+	if len(missing) > 0 {
+		return nil, nil, fmt.Errorf("task %q is missing required argument(s): %s\n%s",
+			task.Name, strings.Join(missing, ", "), TaskUsage(*task))
+	}
+
+	return positional, values, nil
+}
+
+func checkArgValue(spec models.TaskArg, value string) (string, error) {
+	switch spec.Type {
+	case models.ArgTypeInt:
+		if _, err := strconv.Atoi(value); err != nil {
+			return "", fmt.Errorf("%q is not an integer", value)
+		}
+	case models.ArgTypeBool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return "", fmt.Errorf("%q is not a boolean", value)
+		}
+		value = strconv.FormatBool(b)
+	}
+
+	if len(spec.Choices) > 0 && !slices.Contains(spec.Choices, value) {
+		return "", fmt.Errorf("%q is not one of: %s", value, strings.Join(spec.Choices, ", "))
+	}
+
+	return value, nil
+}
+
+// TaskUsage renders a usage line for the task followed by a description of
+// each declared argument.
+func TaskUsage(task models.Task) string {
+	var builder strings.Builder
+	builder.WriteString("Usage: pace run " + task.Name)
+
+	if task.Args == nil {
+		builder.WriteString(" [args...]")
+		return builder.String()
+	}
+
+	specs := argSpecs(task.Args)
+	for _, spec := range specs {
+		builder.WriteString(" " + usageToken(spec, slices.Contains(task.Args.Required, spec.Name)))
+	}
+
+	if len(specs) == 0 {
+		return builder.String()
+	}
+
+	builder.WriteString("\n\nArguments:")
+	for _, spec := range specs {
+		details := make([]string, 0, 2)
+		switch {
+		case spec.HasDefault:
+			details = append(details, fmt.Sprintf("default %q", spec.Default))
+		case spec.Type == models.ArgTypeBool:
+			details = append(details, "default false")
+		case slices.Contains(task.Args.Required, spec.Name):
+			details = append(details, "required")
+		default:
+			details = append(details, "optional")
+		}
+		if len(spec.Choices) > 0 {
+			details = append(details, "one of: "+strings.Join(spec.Choices, ", "))
+		}
+		builder.WriteString(fmt.Sprintf("\n  --%-18s %-7s %s", spec.Name, spec.Type, strings.Join(details, "; ")))
+	}
+
+	return builder.String()
+}
+
+func usageToken(spec models.TaskArg, required bool) string {
+	var token string
+	switch {
+	case spec.Type == models.ArgTypeBool:
+		token = "--" + spec.Name
+	case len(spec.Choices) > 0:
+		token = fmt.Sprintf("--%s=<%s>", spec.Name, strings.Join(spec.Choices, "|"))
+	default:
+		token = fmt.Sprintf("--%s=<%s>", spec.Name, spec.Type)
+	}
+	if required {
+		return token
+	}
+	return "[" + token + "]"
+}
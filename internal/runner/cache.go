@@ -7,8 +7,11 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"time"
+
+	"github.com/azuyamat/pace/internal/models"
 )
 
 const cacheDir = ".pace-cache"
@@ -83,9 +86,13 @@ func ensureCacheDir() error {
 }
 
 func getCachePath(taskName string) string {
-	return filepath.Join(cacheDir, taskName+".json")
+	return filepath.Join(cacheDir, cacheFileName(taskName)+".json")
 }
 
+// cacheFileName escapes the characters of workspace task names, such as
+// "services/api:build", that cannot appear in a file name.
+var cacheFileName = strings.NewReplacer("%", "%25", "/", "%2F", "\\", "%5C", ":", "%3A").Replace
+
 func loadCache(taskName string) (*TaskCache, error) {
 	mutexVal, _ := cacheLocks.LoadOrStore(taskName, &sync.Mutex{})
 	mutex := mutexVal.(*sync.Mutex)
@@ -136,7 +143,10 @@ func saveCache(cache *TaskCache) error {
 }
 
 func (r *Runner) needsRerun(taskName string) (bool, error) {
-	task, exists := r.Config.Tasks[taskName]
+	task, exists, err := r.lookupTask(taskName)
+	if err != nil {
+		return false, err
+	}
 	if !exists {
 		return false, fmt.Errorf("task %q not found", taskName)
 	}
@@ -168,7 +178,10 @@ func (r *Runner) needsRerun(taskName string) (bool, error) {
 	}
 
 	for _, depName := range task.DependsOn {
-		depTask, exists := r.Config.Tasks[depName]
+		depTask, exists, err := r.lookupTask(depName)
+		if err != nil {
+			return false, err
+		}
 		if !exists {
 			continue
 		}
@@ -198,7 +211,7 @@ func (r *Runner) needsRerun(taskName string) (bool, error) {
 		}
 	}
 
-	currentInputsHash, err := computeFilesHash(task.Inputs)
+	currentInputsHash, err := computeFilesHash(r.cacheInputs(task))
 	if err != nil {
 		return false, err
 	}
@@ -242,8 +255,42 @@ func (r *Runner) needsRerun(taskName string) (bool, error) {
 	return false, nil // Cache is valid, no need to run
 }
 
+// isAffected reports whether the task's inputs match one of
+// r.ChangedFiles, or whether one of its dependencies, directly or
+// transitively, is affected.
+func (r *Runner) isAffected(taskName string, visited map[string]bool) (bool, error) {
+	if visited[taskName] {
+		return false, nil
+	}
+	visited[taskName] = true
+
+	task, exists, err := r.lookupTask(taskName)
+	if err != nil || !exists {
+		return false, err
+	}
+
+	for _, pattern := range r.cacheInputs(task) {
+		for _, file := range r.ChangedFiles {
+			if matchesGlobPattern(pattern, file) {
+				return true, nil
+			}
+		}
+	}
+
+	for _, depName := range task.DependsOn {
+		affected, err := r.isAffected(depName, visited)
+		if err != nil || affected {
+			return affected, err
+		}
+	}
+	return false, nil
+}
+
 func (r *Runner) updateCache(taskName string) error {
-	task, exists := r.Config.Tasks[taskName]
+	task, exists, err := r.lookupTask(taskName)
+	if err != nil {
+		return err
+	}
 	if !exists {
 		return fmt.Errorf("task %q not found", taskName)
 	}
@@ -252,7 +299,7 @@ func (r *Runner) updateCache(taskName string) error {
 		return nil
 	}
 
-	inputsHash, err := computeFilesHash(task.Inputs)
+	inputsHash, err := computeFilesHash(r.cacheInputs(task))
 	if err != nil {
 		return err
 	}
@@ -264,7 +311,10 @@ func (r *Runner) updateCache(taskName string) error {
 
 	depHashes := make(map[string]string)
 	for _, depName := range task.DependsOn {
-		depTask, exists := r.Config.Tasks[depName]
+		depTask, exists, err := r.lookupTask(depName)
+		if err != nil {
+			return err
+		}
 		if !exists {
 			continue
 		}
@@ -290,3 +340,27 @@ func (r *Runner) updateCache(taskName string) error {
 
 	return saveCache(cache)
 }
+
+// lookupTask returns the configured task with dynamic variables expanded, so
+// that cache hashes reflect their evaluated values.
+func (r *Runner) lookupTask(taskName string) (models.Task, bool, error) {
+	task, exists := r.Config.Tasks[taskName]
+	if !exists {
+		return task, false, nil
+	}
+	task, err := r.vars.ExpandTask(task)
+	if err != nil {
+		return task, true, fmt.Errorf("task %q: %v", taskName, err)
+	}
+	return task, true, nil
+}
+
+// cacheInputs returns the task inputs together with the env files it loads,
+// so that editing an env file invalidates the cache.
+func (r *Runner) cacheInputs(task models.Task) []string {
+	inputs := make([]string, 0, len(task.Inputs)+len(r.Config.EnvFiles)+len(task.EnvFiles))
+	inputs = append(inputs, task.Inputs...)
+	inputs = append(inputs, r.Config.EnvFiles...)
+	inputs = append(inputs, task.EnvFiles...)
+	return inputs
+}
@@ -76,13 +76,19 @@ func (dr *DependencyRunner) RunDependenciesWithContext(ctx context.Context, task
 
 type HookExecutor struct {
 	hooks    map[string]models.Hook
+	env      map[string]string
+	dir      string
+	vars     *DynamicVars
 	executor *Executor
 	log      taskLogger
 }
 
-func NewHookExecutor(hooks map[string]models.Hook, executor *Executor, log taskLogger) *HookExecutor {
+func NewHookExecutor(hooks map[string]models.Hook, env map[string]string, dir string, vars *DynamicVars, executor *Executor, log taskLogger) *HookExecutor {
 	return &HookExecutor{
 		hooks:    hooks,
+		env:      env,
+		dir:      dir,
+		vars:     vars,
 		executor: executor,
 		log:      log,
 	}
@@ -94,6 +100,15 @@ func (he *HookExecutor) ExecuteHooks(hookNames []string) error {
 		if !exists {
 			return fmt.Errorf("hook %q not found", hookName)
 		}
+		hook, err := he.vars.ExpandHook(hook)
+		if err != nil {
+			return fmt.Errorf("hook %q: %v", hookName, err)
+		}
+		env, err := layerEnv(he.env, he.dir, hook.EnvFiles, hook.Env)
+		if err != nil {
+			return fmt.Errorf("failed to load env files for hook %q: %v", hookName, err)
+		}
+		hook.Env = env
 		if err := he.executor.ExecuteHook(hookName, &hook); err != nil {
 			return fmt.Errorf("hook %q failed: %v", hookName, err)
 		}
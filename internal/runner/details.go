@@ -0,0 +1,186 @@
+package runner
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/azuyamat/pace/internal/config"
+	"github.com/azuyamat/pace/internal/models"
+)
+
+// TaskDetails is a task as it would run: templates merged, variables
+// resolved, env files layered and input patterns expanded.
+type TaskDetails struct {
+	Name        string       `json:"name"`
+	Alias       string       `json:"alias,omitempty"`
+	Extends     string       `json:"extends,omitempty"`
+	Source      string       `json:"source"`
+	Description string       `json:"description,omitempty"`
+	Command     string       `json:"command"`
+	WorkingDir  string       `json:"working_dir,omitempty"`
+	DependsOn   []string     `json:"depends_on"`
+	Inputs      []InputMatch `json:"inputs"`
+	Outputs     []string     `json:"outputs"`
+	// Env holds the layered environment. Values loaded from env files are
+	// replaced by hiddenEnvValue, since env files often hold secrets.
+	Env         map[string]string   `json:"env"`
+	Hooks       []HookDetails       `json:"hooks"`
+	Args        []ArgDetails        `json:"args"`
+	Variables   map[string]string   `json:"variables,omitempty"`
+	Settings    map[string]any      `json:"settings"`
+	Ready       *models.ReadyCheck  `json:"ready,omitempty"`
+	Watch       models.WatchOptions `json:"watch"`
+	WatchInputs []string            `json:"watch_inputs,omitempty"`
+}
+
+// hiddenEnvValue stands for the value of a variable loaded from an env file.
+const hiddenEnvValue = "(hidden)"
+
+// ArgDetails is an argument the task accepts.
+type ArgDetails struct {
+	models.TaskArg
+	Required bool `json:"required"`
+}
+
+// InputMatch is an input pattern with the files it currently matches.
+type InputMatch struct {
+	Pattern string   `json:"pattern"`
+	Files   []string `json:"files"`
+}
+
+// HookDetails is a hook attached to a task, in the order it runs.
+type HookDetails struct {
+	Stage      string `json:"stage"`
+	Name       string `json:"name"`
+	Command    string `json:"command"`
+	WorkingDir string `json:"working_dir,omitempty"`
+	Source     string `json:"source"`
+}
+
+// DescribeTask resolves a task the way a run would, without running it.
+// Dynamic variables the task references are evaluated.
+func DescribeTask(cfg *config.Config, task models.Task) (*TaskDetails, error) {
+	shell := NewShell(cfg.Globals)
+	vars := NewDynamicVars(cfg.DynamicVars, cfg.DotEnv, shell)
+
+	usedVars := vars.Referenced(taskStrings(task)...)
+	task, err := vars.ExpandTask(task)
+	if err != nil {
+		return nil, fmt.Errorf("task %q: %v", task.Name, err)
+	}
+
+	env, err := layerEnv(cfg.DotEnv, cfg.Dir, task.EnvFiles, task.Env)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load env files for task %q: %v", task.Name, err)
+	}
+	for key := range env {
+		if _, inline := task.Env[key]; !inline {
+			env[key] = hiddenEnvValue
+		}
+	}
+
+	watchOptions := models.DefaultWatchOptions()
+	if task.WatchOptions != nil {
+		watchOptions = *task.WatchOptions
+	}
+
+	details := &TaskDetails{
+		Name:        task.Name,
+		Alias:       task.Alias,
+		Extends:     task.Extends,
+		Source:      task.Source.String(),
+		Description: task.Description,
+		Command:     task.Command,
+		WorkingDir:  task.WorkingDir,
+		DependsOn:   nonNil(task.DependsOn),
+		Inputs:      make([]InputMatch, 0, len(task.Inputs)),
+		Outputs:     nonNil(task.Outputs),
+		Env:         env,
+		Hooks:       make([]HookDetails, 0),
+		Args:        make([]ArgDetails, 0),
+		Ready:       task.Ready,
+		Watch:       watchOptions,
+		WatchInputs: task.WatchInputs,
+		Settings: map[string]any{
+			"cache":             task.Cache,
+			"service":           task.Service,
+			"parallel":          task.Parallel,
+			"silent":            task.Silent,
+			"continue_on_error": task.ContinueOnError,
+			"watch":             task.Watch,
+			"retry":             task.Retry,
+		},
+	}
+	for key, value := range map[string]string{
+		"timeout":     task.Timeout,
+		"retry_delay": task.RetryDelay,
+		"when":        task.When,
+		"restart":     string(task.Restart),
+	} {
+		if value != "" {
+			details.Settings[key] = value
+		}
+	}
+
+	if task.Args != nil {
+		for _, spec := range argSpecs(task.Args) {
+			details.Args = append(details.Args, ArgDetails{
+				TaskArg:  spec,
+				Required: slices.Contains(task.Args.Required, spec.Name),
+			})
+		}
+	}
+
+	if len(usedVars) > 0 {
+		details.Variables = make(map[string]string, len(usedVars))
+		for _, name := range usedVars {
+			details.Variables[name], _ = vars.Value(name)
+		}
+	}
+
+	for _, pattern := range task.Inputs {
+		files, err := expandGlobPattern(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("failed to expand input %q: %v", pattern, err)
+		}
+		details.Inputs = append(details.Inputs, InputMatch{Pattern: pattern, Files: nonNil(files)})
+	}
+
+	stages := []struct {
+		name  string
+		hooks []string
+	}{
+		{"before", task.Requires},
+		{"after", task.Triggers},
+		{"on_success", task.OnSuccess},
+		{"on_failure", task.OnFailure},
+	}
+	for _, stage := range stages {
+		for _, name := range stage.hooks {
+			hook, exists := cfg.GetHook(name)
+			if !exists {
+				return nil, fmt.Errorf("hook %q not found for task %q", name, task.Name)
+			}
+			hook, err := vars.ExpandHook(hook)
+			if err != nil {
+				return nil, fmt.Errorf("hook %q: %v", name, err)
+			}
+			details.Hooks = append(details.Hooks, HookDetails{
+				Stage:      stage.name,
+				Name:       name,
+				Command:    hook.Command,
+				WorkingDir: hook.WorkingDir,
+				Source:     hook.Source.String(),
+			})
+		}
+	}
+
+	return details, nil
+}
+
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
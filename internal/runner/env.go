@@ -0,0 +1,47 @@
+package runner
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/azuyamat/pace/internal/config/dotenv"
+)
+
+// layerEnv builds the extra environment for a command. Variables from the
+// config-level env files come first, then the command's own env files, then
+// its inline env map. All of them override the OS environment. Relative env
+// files were rebased from the file declaring them onto dir, the directory of
+// the main config file, when the config was loaded, so they are read from
+// there whatever the current directory.
+func layerEnv(base map[string]string, dir string, files []string, inline map[string]string) (map[string]string, error) {
+	env := make(map[string]string, len(base)+len(inline))
+	for key, value := range base {
+		env[key] = value
+	}
+
+	paths := make([]string, len(files))
+	for i, file := range files {
+		paths[i] = file
+		if dir != "" && !filepath.IsAbs(file) {
+			paths[i] = filepath.Join(dir, file)
+		}
+	}
+
+	fileEnv, err := dotenv.LoadFiles(paths, func(name string) (string, bool) {
+		if value, ok := env[name]; ok {
+			return value, true
+		}
+		return os.LookupEnv(name)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for key, value := range fileEnv {
+		env[key] = value
+	}
+	for key, value := range inline {
+		env[key] = value
+	}
+	return env, nil
+}
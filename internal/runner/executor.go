@@ -6,20 +6,22 @@ import (
 	"io"
 	"os"
 	"os/exec"
-	"strconv"
-	"strings"
 	"time"
 
 	"github.com/azuyamat/pace/internal/models"
+	"golang.org/x/term"
 )
 
 type Executor struct {
-	shell  *Shell
-	log    taskLogger
-	DryRun bool
+	shell    *Shell
+	log      taskLogger
+	stdin    io.Reader
+	services *serviceSet
+	DryRun   bool
 }
 
 type taskLogger interface {
+	Info(format string, args ...interface{})
 	Task(format string, args ...interface{})
 	Success(format string, args ...interface{})
 	Warning(format string, args ...interface{})
@@ -27,53 +29,14 @@ type taskLogger interface {
 
 func NewExecutor(shell *Shell, log taskLogger, dryRun bool) *Executor {
 	return &Executor{
-		shell:  shell,
-		log:    log,
-		DryRun: dryRun,
+		shell:    shell,
+		log:      log,
+		stdin:    os.Stdin,
+		services: newServiceSet(),
+		DryRun:   dryRun,
 	}
 }
 
-// interpolateArgs replaces argument placeholders in the command string
-// Supports:
-//
-//	$@ - all arguments
-//	$1, $2, $3, etc. - individual arguments by position
-//	$argname - named arguments (when task.Args is defined)
-func interpolateArgs(command string, args []string, task *models.Task) string {
-	result := command
-	replaced := make(map[string]bool)
-
-	if task.Args != nil {
-		allArgNames := append(task.Args.Required, task.Args.Optional...)
-		for i, argName := range allArgNames {
-			if i < len(args) {
-				placeholder := "$" + argName
-				key := placeholder + "_named"
-				if strings.Contains(result, placeholder) && !replaced[key] {
-					result = strings.ReplaceAll(result, placeholder, args[i])
-					replaced[key] = true
-				}
-			}
-		}
-	}
-
-	for i := len(args); i >= 1; i-- {
-		placeholder := "$" + strconv.Itoa(i)
-		key := placeholder
-		if strings.Contains(result, placeholder) && !replaced[key] {
-			result = strings.ReplaceAll(result, placeholder, args[i-1])
-			replaced[key] = true
-		}
-	}
-
-	if strings.Contains(result, "$@") && !replaced["$@"] {
-		allArgs := strings.Join(args, " ")
-		result = strings.ReplaceAll(result, "$@", allArgs)
-	}
-
-	return result
-}
-
 func (e *Executor) ExecuteTask(taskName string, task *models.Task, beforeHooks, afterHooks func([]string) error, updateCache func() error) error {
 	return e.ExecuteTaskWithContext(context.Background(), taskName, task, beforeHooks, afterHooks, updateCache)
 }
@@ -89,24 +52,8 @@ func (e *Executor) ExecuteTaskWithContext(ctx context.Context, taskName string,
 		e.log.Task("Running task %q...", taskName)
 	}
 
-	originalDir, err := os.Getwd()
-	if err != nil {
-		return fmt.Errorf("failed to get current working directory: %v", err)
-	}
-	workingDir := task.WorkingDir
-	if workingDir != "" {
-		if err := os.Chdir(workingDir); err != nil {
-			return fmt.Errorf("failed to change directory to %q: %v", workingDir, err)
-		}
-		defer func() {
-			if err := os.Chdir(originalDir); err != nil {
-				e.log.Warning("failed to revert to original directory %q: %v", originalDir, err)
-			}
-		}()
-	}
-
 	shell, shellArgs := e.shell.GetShellCommand()
-	commandStr := interpolateArgs(task.Command, task.ExtraArgs, task)
+	commandStr := interpolateArgs(task.Command, task.ExtraArgs, task, e.shell.Quoting())
 	cmdArgs := append(shellArgs, commandStr)
 
 	execCtx := ctx
@@ -121,6 +68,18 @@ func (e *Executor) ExecuteTaskWithContext(ctx context.Context, taskName string,
 	}
 
 	cmd := exec.CommandContext(execCtx, shell, cmdArgs...)
+	// The directory is set on the command rather than the process so that
+	// tasks running concurrently do not change each other's directory.
+	cmd.Dir = task.WorkingDir
+	cmd.WaitDelay = time.Second
+	if isTerminal(e.stdin) {
+		// A task in a process group of its own would be stopped on reading
+		// the terminal, so it stays in the foreground, where Ctrl+C reaches
+		// it directly.
+		interruptOnCancel(cmd)
+	} else {
+		setProcessGroup(cmd)
+	}
 	cmd.Env = os.Environ()
 	for key, value := range task.Env {
 		cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%s", key, value))
@@ -138,7 +97,7 @@ func (e *Executor) ExecuteTaskWithContext(ctx context.Context, taskName string,
 		cmd.Stdout = stdoutWriter
 		cmd.Stderr = stderrWriter
 	}
-	cmd.Stdin = os.Stdin
+	cmd.Stdin = e.stdin
 
 	cmdErr := cmd.Run()
 
@@ -173,23 +132,18 @@ func (e *Executor) ExecuteTaskWithContext(ctx context.Context, taskName string,
 	return nil
 }
 
+// isTerminal reports whether stdin is a terminal.
+func isTerminal(stdin io.Reader) bool {
+	file, ok := stdin.(*os.File)
+	return ok && term.IsTerminal(int(file.Fd()))
+}
+
 func (e *Executor) ExecuteHook(hookName string, hook *models.Hook) error {
 	e.log.Task("Running hook %q...", hookName)
-	originalDir, err := os.Getwd()
-	if err != nil {
-		return fmt.Errorf("failed to get current working directory: %v", err)
-	}
-	workingDir := hook.WorkingDir
-	if workingDir != "" {
-		if err := os.Chdir(workingDir); err != nil {
-			return fmt.Errorf("failed to change directory to %q: %v", workingDir, err)
-		}
-		defer os.Chdir(originalDir)
-	}
-
 	shell, shellArgs := e.shell.GetShellCommand()
 	cmdArgs := append(shellArgs, hook.Command)
 	cmd := exec.Command(shell, cmdArgs...)
+	cmd.Dir = hook.WorkingDir
 
 	cmd.Env = os.Environ()
 	for key, value := range hook.Env {
@@ -203,10 +157,9 @@ func (e *Executor) ExecuteHook(hookName string, hook *models.Hook) error {
 
 	cmd.Stdout = stdoutWriter
 	cmd.Stderr = stderrWriter
-	cmd.Stdin = os.Stdin
+	cmd.Stdin = e.stdin
 
-	err = cmd.Run()
-	if err != nil {
+	if err := cmd.Run(); err != nil {
 		return fmt.Errorf("failed to run hook %q: %v", hookName, err)
 	}
 
@@ -0,0 +1,53 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+)
+
+// ChangedFiles returns the files that differ from base: changed in commits
+// since the merge base with base, modified in the working tree, or
+// untracked. Paths are relative to the current directory, and files outside
+// of it are left out.
+func ChangedFiles(base string) ([]string, error) {
+	queries := [][]string{
+		{"diff", "--name-only", "--relative", base + "...HEAD"},
+		{"diff", "--name-only", "--relative", "HEAD"},
+		{"ls-files", "--others", "--exclude-standard"},
+	}
+
+	seen := make(map[string]bool)
+	for _, query := range queries {
+		files, err := gitLines(query...)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			seen[file] = true
+		}
+	}
+	return sortedNames(seen), nil
+}
+
+func gitLines(args ...string) ([]string, error) {
+	out, err := exec.Command("git", args...).Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+			return nil, fmt.Errorf("git %s: %s", strings.Join(args, " "), strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return nil, fmt.Errorf("git %s: %v", strings.Join(args, " "), err)
+	}
+
+	lines := make([]string, 0)
+	for _, line := range strings.Split(string(out), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	sort.Strings(lines)
+	return lines, nil
+}
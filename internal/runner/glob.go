@@ -3,6 +3,7 @@ package runner
 import (
 	"io/fs"
 	"path/filepath"
+	"strings"
 
 	"github.com/azuyamat/globber/glob"
 )
@@ -29,3 +30,24 @@ func matchesGlobPattern(pattern, filePath string) bool {
 	matches, _ := matcher.Matches(normalizedPath)
 	return matches
 }
+
+// globStaticPrefix returns the directory a pattern is rooted at: the path
+// components before the first one containing a wildcard. recursive reports
+// whether the pattern can match files in subdirectories of that directory.
+func globStaticPrefix(pattern string) (dir string, recursive bool) {
+	parts := strings.Split(filepath.ToSlash(pattern), "/")
+	for i, part := range parts {
+		if !strings.ContainsAny(part, "*?[{") {
+			continue
+		}
+		prefix := strings.Join(parts[:i], "/")
+		if prefix == "" && i > 0 {
+			prefix = "/"
+		}
+		if prefix == "" {
+			prefix = "."
+		}
+		return filepath.Clean(filepath.FromSlash(prefix)), i < len(parts)-1 || strings.Contains(part, "**")
+	}
+	return filepath.Dir(filepath.FromSlash(pattern)), false
+}
@@ -0,0 +1,192 @@
+package runner
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/azuyamat/pace/internal/config"
+	"github.com/azuyamat/pace/internal/models"
+)
+
+const (
+	NodeTask = "task"
+	NodeHook = "hook"
+)
+
+// Edge types. A depends_on edge points from a task to a task it depends on;
+// the others point from a task to a hook it runs at that stage.
+const (
+	EdgeDependsOn = "depends_on"
+	EdgeBefore    = "before"
+	EdgeAfter     = "after"
+	EdgeOnSuccess = "on_success"
+	EdgeOnFailure = "on_failure"
+)
+
+// GraphNode is a task or hook in a Graph. IDs are prefixed with the node
+// kind, since a task and a hook may share a name.
+type GraphNode struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Kind        string `json:"kind"`
+	Description string `json:"description,omitempty"`
+	Default     bool   `json:"default,omitempty"`
+}
+
+// GraphEdge connects two nodes by their IDs.
+type GraphEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Type string `json:"type"`
+}
+
+// Graph is the task and hook graph of a configuration, or the part of it
+// reachable from Root.
+type Graph struct {
+	Root    string      `json:"root,omitempty"`
+	Reverse bool        `json:"reverse,omitempty"`
+	Nodes   []GraphNode `json:"nodes"`
+	Edges   []GraphEdge `json:"edges"`
+}
+
+// BuildGraph returns the graph of every task and hook when name is empty.
+// Otherwise it returns the nodes name leads to, or with reverse the nodes
+// that lead to name, i.e. the tasks that depend on a task or use a hook.
+// Edges keep their direction in both cases.
+func BuildGraph(cfg *config.Config, name string, reverse bool) (*Graph, error) {
+	graph := fullGraph(cfg)
+	if name == "" {
+		return graph, nil
+	}
+
+	root, exists := graphNodeID(cfg, name)
+	if !exists {
+		return nil, fmt.Errorf("task or hook '%s' not found", name)
+	}
+
+	next := make(map[string][]string)
+	for _, edge := range graph.Edges {
+		if reverse {
+			next[edge.To] = append(next[edge.To], edge.From)
+		} else {
+			next[edge.From] = append(next[edge.From], edge.To)
+		}
+	}
+
+	keep := map[string]bool{root: true}
+	queue := []string{root}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		for _, neighbour := range next[id] {
+			if !keep[neighbour] {
+				keep[neighbour] = true
+				queue = append(queue, neighbour)
+			}
+		}
+	}
+
+	sub := &Graph{
+		Root:    root,
+		Reverse: reverse,
+		Nodes:   make([]GraphNode, 0, len(keep)),
+		Edges:   make([]GraphEdge, 0),
+	}
+	for _, node := range graph.Nodes {
+		if keep[node.ID] {
+			sub.Nodes = append(sub.Nodes, node)
+		}
+	}
+	for _, edge := range graph.Edges {
+		if keep[edge.From] && keep[edge.To] {
+			sub.Edges = append(sub.Edges, edge)
+		}
+	}
+	return sub, nil
+}
+
+func fullGraph(cfg *config.Config) *Graph {
+	graph := &Graph{
+		Nodes: make([]GraphNode, 0, len(cfg.Tasks)+len(cfg.Hooks)),
+		Edges: make([]GraphEdge, 0),
+	}
+
+	taskNames := make([]string, 0, len(cfg.Tasks))
+	for name := range cfg.Tasks {
+		taskNames = append(taskNames, name)
+	}
+	sort.Strings(taskNames)
+
+	hookNames := make([]string, 0, len(cfg.Hooks))
+	for name := range cfg.Hooks {
+		hookNames = append(hookNames, name)
+	}
+	sort.Strings(hookNames)
+
+	for _, name := range taskNames {
+		task := cfg.Tasks[name]
+		graph.Nodes = append(graph.Nodes, GraphNode{
+			ID:          taskNodeID(name),
+			Name:        name,
+			Kind:        NodeTask,
+			Description: task.Description,
+			Default:     cfg.DefaultTask == name,
+		})
+		graph.Edges = append(graph.Edges, taskEdges(task)...)
+	}
+	for _, name := range hookNames {
+		graph.Nodes = append(graph.Nodes, GraphNode{
+			ID:          hookNodeID(name),
+			Name:        name,
+			Kind:        NodeHook,
+			Description: cfg.Hooks[name].Description,
+		})
+	}
+
+	return graph
+}
+
+// taskEdges returns the outgoing edges of a task in the order they apply
+// during a run.
+func taskEdges(task models.Task) []GraphEdge {
+	from := taskNodeID(task.Name)
+	edges := make([]GraphEdge, 0)
+	for _, dep := range task.DependsOn {
+		edges = append(edges, GraphEdge{From: from, To: taskNodeID(dep), Type: EdgeDependsOn})
+	}
+
+	stages := []struct {
+		edgeType string
+		hooks    []string
+	}{
+		{EdgeBefore, task.Requires},
+		{EdgeAfter, task.Triggers},
+		{EdgeOnSuccess, task.OnSuccess},
+		{EdgeOnFailure, task.OnFailure},
+	}
+	for _, stage := range stages {
+		for _, hook := range stage.hooks {
+			edges = append(edges, GraphEdge{From: from, To: hookNodeID(hook), Type: stage.edgeType})
+		}
+	}
+	return edges
+}
+
+// graphNodeID resolves a task name or alias, falling back to a hook name.
+func graphNodeID(cfg *config.Config, name string) (string, bool) {
+	if task, exists := cfg.GetTaskOrDefault(name); exists {
+		return taskNodeID(task.Name), true
+	}
+	if _, exists := cfg.GetHook(name); exists {
+		return hookNodeID(name), true
+	}
+	return "", false
+}
+
+func taskNodeID(name string) string {
+	return NodeTask + ":" + name
+}
+
+func hookNodeID(name string) string {
+	return NodeHook + ":" + name
+}
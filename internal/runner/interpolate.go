@@ -0,0 +1,232 @@
+package runner
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/azuyamat/pace/internal/models"
+)
+
+type QuoteStyle int
+
+const (
+	QuotePOSIX QuoteStyle = iota
+	QuotePowerShell
+	QuoteCmd
+)
+
+var (
+	posixSafeArg = regexp.MustCompile(`^[A-Za-z0-9_@%+=:,./-]+$`)
+	otherSafeArg = regexp.MustCompile(`^[A-Za-z0-9_:./-]+$`)
+)
+
+// interpolateArgs replaces argument placeholders in the command string.
+// Supports:
+//
+//	$@, ${@}          - all positional arguments, each quoted as a word of its own
+//	${@:raw}          - all positional arguments spliced in unquoted
+//	$1, ${1}, $10     - individual arguments by position
+//	$argname, ${name} - named arguments and their defaults (when task.Args is defined)
+//
+// Substituted values are quoted for the shell and for the quoting context
+// they appear in, so they always arrive as a single literal word.
+// Placeholders that do not refer to an argument are left for the shell.
+func interpolateArgs(command string, args []string, task *models.Task, style QuoteStyle) string {
+	var builder strings.Builder
+	var quote byte
+
+	for i := 0; i < len(command); i++ {
+		char := command[i]
+
+		switch {
+		case isEscapeChar(char, style) && quote != '\'' && i+1 < len(command):
+			builder.WriteByte(char)
+			builder.WriteByte(command[i+1])
+			i++
+			continue
+
+		case char == '\'' || char == '"':
+			if quote == 0 && len(args) == 0 && isQuotedAllArgs(command[i:]) {
+				// "$@" without arguments expands to no word at all.
+				i += strings.IndexByte(command[i+1:], char) + 1
+				continue
+			}
+			if quote == 0 {
+				quote = char
+			} else if quote == char {
+				quote = 0
+			}
+
+		case char == '$':
+			if value, width, ok := expandPlaceholder(command[i:], args, task, style, quote); ok {
+				builder.WriteString(value)
+				i += width - 1
+				continue
+			}
+		}
+
+		builder.WriteByte(char)
+	}
+
+	return builder.String()
+}
+
+// isQuotedAllArgs reports whether s starts with "$@" or "${@}" in quotes
+// of their own.
+func isQuotedAllArgs(s string) bool {
+	for _, placeholder := range []string{"$@", "${@}"} {
+		if strings.HasPrefix(s[1:], placeholder+s[:1]) {
+			return true
+		}
+	}
+	return false
+}
+
+func isEscapeChar(char byte, style QuoteStyle) bool {
+	switch style {
+	case QuotePOSIX:
+		return char == '\\'
+	case QuotePowerShell:
+		return char == '`'
+	default:
+		return false
+	}
+}
+
+// expandPlaceholder expands the placeholder at the start of s, returning the
+// replacement and the number of bytes it consumed.
+func expandPlaceholder(s string, args []string, task *models.Task, style QuoteStyle, quote byte) (string, int, bool) {
+	if len(s) < 2 {
+		return "", 0, false
+	}
+
+	if s[1] == '{' {
+		end := strings.IndexByte(s, '}')
+		if end < 0 {
+			return "", 0, false
+		}
+		value, ok := lookupPlaceholder(s[2:end], args, task, style, quote)
+		return value, end + 1, ok
+	}
+
+	if s[1] == '@' {
+		value, ok := lookupPlaceholder("@", args, task, style, quote)
+		return value, 2, ok
+	}
+
+	if isDigitByte(s[1]) {
+		end := 1
+		for end < len(s) && isDigitByte(s[end]) {
+			end++
+		}
+		value, ok := lookupPlaceholder(s[1:end], args, task, style, quote)
+		return value, end, ok
+	}
+
+	// Bare names end at the first character that cannot appear in a shell
+	// variable name. Argument names may contain dashes, so prefixes ending
+	// before a dash are tried from longest to shortest.
+	end := 1
+	for end < len(s) && isNameByte(s[end]) {
+		end++
+	}
+	for end > 1 {
+		if value, ok := lookupNamedArg(s[1:end], task, style, quote); ok {
+			return value, end, true
+		}
+		dash := strings.LastIndexByte(s[1:end], '-')
+		if dash < 0 {
+			break
+		}
+		end = dash + 1
+	}
+
+	return "", 0, false
+}
+
+func lookupPlaceholder(name string, args []string, task *models.Task, style QuoteStyle, quote byte) (string, bool) {
+	switch name {
+	case "@":
+		// Each argument stays a word of its own, as with "$@" in sh: inside
+		// quotes, the quotes are closed and reopened between arguments.
+		quoted := make([]string, len(args))
+		for i, arg := range args {
+			quoted[i] = quoteArg(arg, style, quote)
+		}
+		separator := " "
+		if quote != 0 {
+			separator = string(quote) + " " + string(quote)
+		}
+		return strings.Join(quoted, separator), true
+	case "@:raw":
+		return strings.Join(args, " "), true
+	}
+
+	if index, err := strconv.Atoi(name); err == nil {
+		if index < 1 || index > len(args) {
+			return "", false
+		}
+		return quoteArg(args[index-1], style, quote), true
+	}
+
+	return lookupNamedArg(name, task, style, quote)
+}
+
+func lookupNamedArg(name string, task *models.Task, style QuoteStyle, quote byte) (string, bool) {
+	if task.Args == nil {
+		return "", false
+	}
+	value, ok := task.ArgValues[name]
+	if !ok {
+		return "", false
+	}
+	return quoteArg(value, style, quote), true
+}
+
+// quoteArg quotes value so that the shell reads it as literal text. quote is
+// the quote character the placeholder is enclosed in, or 0 when it is bare.
+func quoteArg(value string, style QuoteStyle, quote byte) string {
+	switch style {
+	case QuotePowerShell:
+		switch quote {
+		case '\'':
+			return strings.ReplaceAll(value, "'", "''")
+		case '"':
+			return strings.NewReplacer("`", "``", "\"", "`\"", "$", "`$").Replace(value)
+		}
+		if otherSafeArg.MatchString(value) {
+			return value
+		}
+		return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+
+	case QuoteCmd:
+		if quote == '"' {
+			return strings.ReplaceAll(value, "\"", "\"\"")
+		}
+		if otherSafeArg.MatchString(value) {
+			return value
+		}
+		return "\"" + strings.ReplaceAll(value, "\"", "\"\"") + "\""
+
+	default:
+		switch quote {
+		case '\'':
+			return strings.ReplaceAll(value, "'", `'\''`)
+		case '"':
+			return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", `\$`, "`", "\\`").Replace(value)
+		}
+		if posixSafeArg.MatchString(value) {
+			return value
+		}
+		return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+	}
+}
+
+func isDigitByte(char byte) bool {
+	return char >= '0' && char <= '9'
+}
+
+func isNameByte(char byte) bool {
+	return (char >= 'a' && char <= 'z') || (char >= 'A' && char <= 'Z') || isDigitByte(char) || char == '_' || char == '-'
+}
@@ -0,0 +1,41 @@
+package runner
+
+import (
+	"os"
+
+	"golang.org/x/term"
+)
+
+const keyHelp = "Press r or Enter to rerun, f to force a rerun ignoring the cache, c to clear, p to pause, q to quit"
+
+// readKeys reads single keystrokes from stdin when it is a terminal. The
+// returned function restores the terminal; ok is false when stdin is not a
+// terminal or cannot be switched to key input.
+func readKeys() (keys <-chan byte, restore func(), ok bool) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return nil, nil, false
+	}
+
+	restore, err := enableKeyInput(fd)
+	if err != nil {
+		return nil, nil, false
+	}
+
+	ch := make(chan byte)
+	go func() {
+		buf := make([]byte, 1)
+		for {
+			n, err := os.Stdin.Read(buf)
+			if err != nil {
+				close(ch)
+				return
+			}
+			if n == 1 {
+				ch <- buf[0]
+			}
+		}
+	}()
+
+	return ch, restore, true
+}
@@ -0,0 +1,330 @@
+package runner
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/azuyamat/pace/internal/config"
+	"github.com/azuyamat/pace/internal/models"
+)
+
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+	SeverityInfo    Severity = "info"
+)
+
+// Finding is a problem reported by Lint, at the definition it concerns.
+type Finding struct {
+	Rule     string        `json:"rule"`
+	Severity Severity      `json:"severity"`
+	Message  string        `json:"message"`
+	Source   models.Source `json:"source"`
+}
+
+// LintRule is a check run by Lint. Its ID names it in findings and in
+// suppressions.
+type LintRule struct {
+	ID          string
+	Severity    Severity
+	Description string
+	check       func(l *linter, report reportFunc)
+}
+
+type reportFunc func(source models.Source, format string, args ...any)
+
+// LintRules are the checks run by Lint, beyond the validation every config
+// goes through when it is loaded.
+var LintRules = []LintRule{
+	{"alias-shadows-task", SeverityError, "An alias has the name of a task, which can then no longer be run by name", (*linter).aliasesShadowingTasks},
+	{"watch-without-inputs", SeverityError, "A task has watch enabled but no inputs or watch_inputs to watch", (*linter).watchWithoutInputs},
+	{"empty-input", SeverityWarning, "An inputs pattern matches no files", (*linter).emptyInputs},
+	{"overlapping-outputs", SeverityWarning, "Two tasks write to the same outputs", (*linter).overlappingOutputs},
+	{"output-in-inputs", SeverityWarning, "A task writes into the inputs of a task that does not depend on it", (*linter).outputsInInputs},
+	{"unused-hook", SeverityInfo, "A hook is not used by any task", (*linter).unusedHooks},
+	{"unused-var", SeverityInfo, "A variable is never referenced", (*linter).unusedVars},
+}
+
+// ignoreDirective suppresses findings on its line or the line below, for
+// the listed rules or all of them: "# pace:ignore unused-hook".
+var ignoreDirective = regexp.MustCompile(`pace:ignore\b([\w\s,-]*)`)
+
+// Lint runs LintRules against a loaded config and returns the findings
+// sorted by position. Rules listed in ignore, and findings suppressed by a
+// pace:ignore comment, are left out.
+func Lint(cfg *config.Config, ignore []string) []Finding {
+	l := &linter{
+		config:  cfg,
+		matches: make(map[string][]string),
+		lines:   make(map[string][]string),
+	}
+
+	findings := make([]Finding, 0)
+	for _, rule := range LintRules {
+		if slices.Contains(ignore, rule.ID) {
+			continue
+		}
+		rule.check(l, func(source models.Source, format string, args ...any) {
+			finding := Finding{
+				Rule:     rule.ID,
+				Severity: rule.Severity,
+				Message:  fmt.Sprintf(format, args...),
+				Source:   source,
+			}
+			if !l.suppressed(finding) {
+				findings = append(findings, finding)
+			}
+		})
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		a, b := findings[i].Source, findings[j].Source
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		return a.Line < b.Line
+	})
+	return findings
+}
+
+type linter struct {
+	config *config.Config
+	// matches caches the files matched by input and output patterns.
+	matches map[string][]string
+	// lines caches the config files read for pace:ignore comments.
+	lines map[string][]string
+}
+
+func (l *linter) taskNames() []string {
+	names := make([]string, 0, len(l.config.Tasks))
+	for name := range l.config.Tasks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (l *linter) aliasesShadowingTasks(report reportFunc) {
+	aliases := make([]string, 0, len(l.config.Aliases))
+	for alias := range l.config.Aliases {
+		aliases = append(aliases, alias)
+	}
+	sort.Strings(aliases)
+
+	for _, alias := range aliases {
+		target := l.config.Aliases[alias]
+		if task, exists := l.config.Tasks[alias]; exists && target != alias {
+			report(task.Source, "alias '%s' for task '%s' hides task '%s'", alias, target, alias)
+		}
+	}
+}
+
+func (l *linter) watchWithoutInputs(report reportFunc) {
+	for _, name := range l.taskNames() {
+		task := l.config.Tasks[name]
+		if task.Watch && len(task.Inputs) == 0 && len(task.WatchInputs) == 0 {
+			report(task.Source, "task '%s' has watch enabled but no inputs to watch", name)
+		}
+	}
+}
+
+func (l *linter) emptyInputs(report reportFunc) {
+	for _, name := range l.taskNames() {
+		task := l.config.Tasks[name]
+		for _, pattern := range task.Inputs {
+			if isDynamicPattern(pattern) {
+				continue
+			}
+			if len(l.expand(pattern)) == 0 {
+				report(task.Source, "input '%s' of task '%s' matches no files", pattern, name)
+			}
+		}
+	}
+}
+
+func (l *linter) overlappingOutputs(report reportFunc) {
+	names := l.taskNames()
+	for i, name := range names {
+		task := l.config.Tasks[name]
+		for _, other := range names[:i] {
+			if output, otherOutput, found := l.overlap(task.Outputs, l.config.Tasks[other].Outputs); found {
+				report(task.Source, "output '%s' of task '%s' overlaps output '%s' of task '%s'", output, name, otherOutput, other)
+			}
+		}
+	}
+}
+
+func (l *linter) outputsInInputs(report reportFunc) {
+	names := l.taskNames()
+	for _, name := range names {
+		task := l.config.Tasks[name]
+		for _, reader := range names {
+			if reader == name || l.dependsOn(reader, name, make(map[string]bool)) {
+				continue
+			}
+			if output, input, found := l.overlap(task.Outputs, l.config.Tasks[reader].Inputs); found {
+				report(task.Source, "task '%s' writes '%s' into input '%s' of task '%s', which does not depend on it", name, output, input, reader)
+			}
+		}
+	}
+}
+
+func (l *linter) unusedHooks(report reportFunc) {
+	used := make(map[string]bool)
+	for _, task := range l.config.Tasks {
+		for _, hooks := range [][]string{task.Requires, task.Triggers, task.OnSuccess, task.OnFailure} {
+			for _, hook := range hooks {
+				used[hook] = true
+			}
+		}
+	}
+
+	names := make([]string, 0, len(l.config.Hooks))
+	for name := range l.config.Hooks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !used[name] {
+			report(l.config.Hooks[name].Source, "hook '%s' is not used by any task", name)
+		}
+	}
+}
+
+func (l *linter) unusedVars(report reportFunc) {
+	names := make([]string, 0, len(l.config.VarSources))
+	for name := range l.config.VarSources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !l.config.UsedVars[name] {
+			report(l.config.VarSources[name], "variable '%s' is never used", name)
+		}
+	}
+}
+
+// dependsOn reports whether task depends on target, directly or not.
+func (l *linter) dependsOn(task, target string, visited map[string]bool) bool {
+	if visited[task] {
+		return false
+	}
+	visited[task] = true
+	for _, dep := range l.config.Tasks[task].DependsOn {
+		if dep == target || l.dependsOn(dep, target, visited) {
+			return true
+		}
+	}
+	return false
+}
+
+// overlap returns a pattern of a and a pattern of b that can refer to the
+// same files.
+func (l *linter) overlap(a, b []string) (string, string, bool) {
+	for _, patternA := range a {
+		for _, patternB := range b {
+			if l.overlaps(patternA, patternB) {
+				return patternA, patternB, true
+			}
+		}
+	}
+	return "", "", false
+}
+
+// overlaps reports whether two patterns can refer to the same files, going
+// by the patterns themselves and by the files they match now. A plain path
+// also covers the files below it.
+func (l *linter) overlaps(a, b string) bool {
+	if isDynamicPattern(a) || isDynamicPattern(b) {
+		return false
+	}
+	a, b = filepath.Clean(a), filepath.Clean(b)
+	if !isGlob(a) && !isGlob(b) {
+		return within(a, b) || within(b, a)
+	}
+	if covers(a, b) || covers(b, a) {
+		return true
+	}
+	for _, file := range l.expand(a) {
+		if covers(b, file) {
+			return true
+		}
+	}
+	for _, file := range l.expand(b) {
+		if covers(a, file) {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *linter) expand(pattern string) []string {
+	if files, cached := l.matches[pattern]; cached {
+		return files
+	}
+	files, _ := expandGlobPattern(pattern)
+	l.matches[pattern] = files
+	return files
+}
+
+// suppressed reports whether a pace:ignore comment on the line of the
+// finding, or on the line above it, covers its rule.
+func (l *linter) suppressed(finding Finding) bool {
+	source := finding.Source
+	if source.File == "" || source.Line == 0 {
+		return false
+	}
+	lines, cached := l.lines[source.File]
+	if !cached {
+		if data, err := os.ReadFile(source.File); err == nil {
+			lines = strings.Split(string(data), "\n")
+		}
+		l.lines[source.File] = lines
+	}
+
+	for _, index := range []int{source.Line - 1, source.Line - 2} {
+		if index < 0 || index >= len(lines) {
+			continue
+		}
+		match := ignoreDirective.FindStringSubmatch(lines[index])
+		if match == nil {
+			continue
+		}
+		rules := strings.FieldsFunc(match[1], func(r rune) bool { return r == ',' || r == ' ' || r == '\t' || r == '\r' })
+		if len(rules) == 0 || slices.Contains(rules, finding.Rule) {
+			return true
+		}
+	}
+	return false
+}
+
+// covers reports whether pattern matches path, or is a directory holding it.
+func covers(pattern, path string) bool {
+	if isGlob(pattern) {
+		return matchesGlobPattern(pattern, path)
+	}
+	return within(path, pattern)
+}
+
+// within reports whether path is dir or lies below it.
+func within(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+func isGlob(pattern string) bool {
+	return strings.ContainsAny(pattern, "*?[{")
+}
+
+// isDynamicPattern reports whether a pattern references a variable only
+// known when the task runs.
+func isDynamicPattern(pattern string) bool {
+	return strings.Contains(pattern, "${")
+}
@@ -0,0 +1,467 @@
+package runner
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/azuyamat/pace/internal/config"
+	"github.com/azuyamat/pace/internal/models"
+	"golang.org/x/term"
+)
+
+// ErrPickCancelled is returned by PickTask when the picker is closed
+// without choosing a task.
+var ErrPickCancelled = errors.New("no task picked")
+
+// maxRecent is the number of selections remembered for quick reruns.
+const maxRecent = 10
+
+// pickerRows is the number of tasks the picker shows at once.
+const pickerRows = 10
+
+// TaskChoice is a task picked interactively, with its arguments.
+type TaskChoice struct {
+	Task string   `json:"task"`
+	Args []string `json:"args,omitempty"`
+}
+
+func (c TaskChoice) String() string {
+	return strings.TrimSpace(c.Task + " " + strings.Join(c.Args, " "))
+}
+
+type recentChoices struct {
+	Recent []TaskChoice `json:"recent"`
+}
+
+func recentPath() string {
+	return filepath.Join(cacheDir, "recent.json")
+}
+
+// IsInteractive reports whether pace talks to a terminal, where it can
+// open the task picker.
+func IsInteractive() bool {
+	return term.IsTerminal(int(os.Stdin.Fd())) && term.IsTerminal(int(os.Stdout.Fd()))
+}
+
+// TaskSummary is the text shown next to a task name in task lists: its
+// description, or its command when it has none.
+func TaskSummary(task models.Task) string {
+	if task.Description != "" {
+		return task.Description
+	}
+	return task.Command
+}
+
+// LoadRecent returns the remembered selections, most recent first.
+func LoadRecent() []TaskChoice {
+	data, err := os.ReadFile(recentPath())
+	if err != nil {
+		return nil
+	}
+	var recent recentChoices
+	if err := json.Unmarshal(data, &recent); err != nil {
+		return nil
+	}
+	return recent.Recent
+}
+
+// RememberChoice puts a selection first in the recent list.
+func RememberChoice(choice TaskChoice) error {
+	recent := slices.DeleteFunc(LoadRecent(), func(c TaskChoice) bool {
+		return c.String() == choice.String()
+	})
+	recent = append([]TaskChoice{choice}, recent...)
+	if len(recent) > maxRecent {
+		recent = recent[:maxRecent]
+	}
+
+	if err := ensureCacheDir(); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(recentChoices{Recent: recent}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(recentPath(), data, 0644)
+}
+
+// pickerItem is a line of the picker: a task, or a recent selection to run
+// again with the same arguments.
+type pickerItem struct {
+	choice  TaskChoice
+	alias   string
+	summary string
+	recent  bool
+}
+
+func (item pickerItem) label() string {
+	if item.alias != "" {
+		return fmt.Sprintf("%s [%s]", item.choice, item.alias)
+	}
+	return item.choice.String()
+}
+
+// PickTask lets the user search the tasks of cfg by name, alias and
+// description, then asks for the arguments the task declares. Recent
+// selections are listed first and run again with the same arguments.
+func PickTask(cfg *config.Config) (TaskChoice, error) {
+	aliases := make(map[string]string)
+	for alias, target := range cfg.Aliases {
+		if existing, exists := aliases[target]; !exists || alias < existing {
+			aliases[target] = alias
+		}
+	}
+
+	items := make([]pickerItem, 0, len(cfg.Tasks)+maxRecent)
+	for _, choice := range LoadRecent() {
+		if task, exists := cfg.Tasks[choice.Task]; exists && len(choice.Args) > 0 {
+			items = append(items, pickerItem{choice: choice, summary: TaskSummary(task), recent: true})
+		}
+	}
+	recentRank := make(map[string]int)
+	for i, choice := range LoadRecent() {
+		if _, ranked := recentRank[choice.Task]; !ranked {
+			recentRank[choice.Task] = i
+		}
+	}
+	names := make([]string, 0, len(cfg.Tasks))
+	for name := range cfg.Tasks {
+		names = append(names, name)
+	}
+	sort.SliceStable(names, func(i, j int) bool {
+		rankI, recentI := recentRank[names[i]]
+		rankJ, recentJ := recentRank[names[j]]
+		if recentI != recentJ {
+			return recentI
+		}
+		if recentI && rankI != rankJ {
+			return rankI < rankJ
+		}
+		return names[i] < names[j]
+	})
+	for _, name := range names {
+		items = append(items, pickerItem{
+			choice:  TaskChoice{Task: name},
+			alias:   aliases[name],
+			summary: TaskSummary(cfg.Tasks[name]),
+		})
+	}
+	if len(items) == 0 {
+		return TaskChoice{}, fmt.Errorf("there are no tasks to pick from")
+	}
+
+	item, err := pick(items)
+	if err != nil {
+		return TaskChoice{}, err
+	}
+	if item.recent {
+		return item.choice, nil
+	}
+
+	return PromptTaskArgs(cfg.Tasks[item.choice.Task])
+}
+
+// PromptTaskArgs asks for the value of each argument the task declares.
+// Empty answers keep the default.
+func PromptTaskArgs(task models.Task) (TaskChoice, error) {
+	if task.Args == nil {
+		return TaskChoice{Task: task.Name}, nil
+	}
+	args, err := promptArgs(task, bufio.NewReader(os.Stdin), os.Stdout)
+	if err != nil {
+		return TaskChoice{}, err
+	}
+	return TaskChoice{Task: task.Name, Args: args}, nil
+}
+
+// pick shows the items on the terminal and filters them as the user types.
+func pick(items []pickerItem) (pickerItem, error) {
+	fd := int(os.Stdin.Fd())
+	restore, err := enableKeyInput(fd)
+	if err != nil {
+		return pickerItem{}, fmt.Errorf("failed to read keys from the terminal: %v", err)
+	}
+	out := os.Stdout
+	screen := &pickerScreen{out: out, width: 80}
+	if width, _, err := term.GetSize(int(out.Fd())); err == nil && width > 0 {
+		screen.width = width
+	}
+
+	fmt.Fprint(out, "\x1b[?25l")
+	finish := func() {
+		screen.clear()
+		fmt.Fprint(out, "\x1b[?25h")
+		restore()
+	}
+
+	// Ctrl+C still raises an interrupt while keys are read, and the pending
+	// read cannot be abandoned, so the terminal is restored before exiting.
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt)
+	defer func() {
+		signal.Stop(interrupts)
+		close(interrupts)
+	}()
+	go func() {
+		if _, ok := <-interrupts; ok {
+			finish()
+			os.Exit(130)
+		}
+	}()
+
+	query := ""
+	selected := 0
+	matches := filterItems(items, query)
+	buf := make([]byte, 64)
+	for {
+		screen.render(query, matches, selected)
+
+		n, err := os.Stdin.Read(buf)
+		if err != nil {
+			finish()
+			return pickerItem{}, ErrPickCancelled
+		}
+		input := buf[:n]
+
+		switch {
+		case len(input) >= 3 && input[0] == 0x1b && (input[1] == '[' || input[1] == 'O'):
+			switch input[2] {
+			case 'A':
+				selected = max(selected-1, 0)
+			case 'B':
+				selected = min(selected+1, len(matches)-1)
+			}
+			continue
+		case input[0] == 0x1b, input[0] == 0x03, input[0] == 0x04 && query == "":
+			finish()
+			return pickerItem{}, ErrPickCancelled
+		case input[0] == '\r' || input[0] == '\n':
+			if len(matches) == 0 {
+				continue
+			}
+			finish()
+			return matches[selected], nil
+		case input[0] == 0x10:
+			selected = max(selected-1, 0)
+			continue
+		case input[0] == 0x0e || input[0] == '\t':
+			selected = min(selected+1, len(matches)-1)
+			continue
+		case input[0] == 0x7f || input[0] == 0x08:
+			if _, size := utf8.DecodeLastRuneInString(query); size > 0 {
+				query = query[:len(query)-size]
+			}
+		case input[0] == 0x15:
+			query = ""
+		default:
+			for _, r := range string(input) {
+				if unicode.IsPrint(r) {
+					query += string(r)
+				}
+			}
+		}
+		matches = filterItems(items, query)
+		selected = 0
+	}
+}
+
+// pickerScreen draws the picker below the cursor, redrawing it in place.
+type pickerScreen struct {
+	out   io.Writer
+	width int
+	lines int
+}
+
+func (s *pickerScreen) render(query string, matches []pickerItem, selected int) {
+	lines := []string{
+		fmt.Sprintf("\x1b[1mRun task:\x1b[0m %s\x1b[7m \x1b[0m", query),
+	}
+
+	offset := 0
+	if selected >= pickerRows {
+		offset = selected - pickerRows + 1
+	}
+	end := min(offset+pickerRows, len(matches))
+	labelWidth := 0
+	for _, item := range matches[offset:end] {
+		labelWidth = max(labelWidth, utf8.RuneCountInString(item.label()))
+	}
+	for i := offset; i < end; i++ {
+		item := matches[i]
+		marker := "  "
+		if item.recent {
+			marker = "↻ "
+		}
+		summary := strings.Join(strings.Fields(item.summary), " ")
+		line := truncate(fmt.Sprintf("%s%-*s  %s", marker, labelWidth, item.label(), summary), s.width-2)
+		if i == selected {
+			lines = append(lines, "\x1b[36m> "+line+"\x1b[0m")
+		} else {
+			lines = append(lines, "  "+line)
+		}
+	}
+	if len(matches) == 0 {
+		lines = append(lines, "  \x1b[90mNo matching tasks\x1b[0m")
+	} else if len(matches) > end-offset {
+		lines = append(lines, fmt.Sprintf("  \x1b[90m%d of %d tasks, ↑↓ to move, Enter to run, Esc to cancel\x1b[0m", end-offset, len(matches)))
+	} else {
+		lines = append(lines, "  \x1b[90m↑↓ to move, Enter to run, Esc to cancel\x1b[0m")
+	}
+
+	s.clear()
+	fmt.Fprint(s.out, strings.Join(lines, "\r\n"))
+	s.lines = len(lines)
+}
+
+// clear erases what the last render drew and leaves the cursor where it
+// started.
+func (s *pickerScreen) clear() {
+	if s.lines > 1 {
+		fmt.Fprintf(s.out, "\x1b[%dA", s.lines-1)
+	}
+	fmt.Fprint(s.out, "\r\x1b[J")
+	s.lines = 0
+}
+
+func truncate(s string, width int) string {
+	if width <= 0 || utf8.RuneCountInString(s) <= width {
+		return s
+	}
+	runes := []rune(s)
+	return string(runes[:width-1]) + "…"
+}
+
+// filterItems returns the items matching query, best matches first. Names
+// and aliases weigh more than descriptions; ties keep the original order,
+// which lists recent selections first.
+func filterItems(items []pickerItem, query string) []pickerItem {
+	if query == "" {
+		return items
+	}
+
+	type scored struct {
+		item  pickerItem
+		score int
+	}
+	matches := make([]scored, 0, len(items))
+	for _, item := range items {
+		best, found := 0, false
+		for _, field := range []struct {
+			text  string
+			bonus int
+		}{{item.choice.String(), 200}, {item.alias, 150}, {item.summary, 0}} {
+			if score, ok := fuzzyScore(query, field.text); ok && (!found || score+field.bonus > best) {
+				best, found = score+field.bonus, true
+			}
+		}
+		if found {
+			matches = append(matches, scored{item, best})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].score > matches[j].score
+	})
+
+	result := make([]pickerItem, len(matches))
+	for i, match := range matches {
+		result[i] = match.item
+	}
+	return result
+}
+
+// fuzzyScore reports whether the characters of query appear in text in
+// order, ignoring case, and scores the match: consecutive characters,
+// characters starting a word and an early first match score higher.
+func fuzzyScore(query, text string) (int, bool) {
+	if text == "" {
+		return 0, false
+	}
+	queryRunes := []rune(strings.ToLower(query))
+	textRunes := []rune(strings.ToLower(text))
+
+	score, next, first, previous := 0, 0, -1, -2
+	for i, r := range textRunes {
+		if next == len(queryRunes) {
+			break
+		}
+		if r != queryRunes[next] {
+			continue
+		}
+		score += 10
+		if i == previous+1 {
+			score += 15
+		}
+		if i == 0 || strings.ContainsRune(" -_:./[", textRunes[i-1]) {
+			score += 10
+		}
+		if first < 0 {
+			first = i
+		}
+		previous = i
+		next++
+	}
+	if next < len(queryRunes) {
+		return 0, false
+	}
+	return score - first, true
+}
+
+// promptArgs returns the answers of the user as --name=value flags.
+func promptArgs(task models.Task, in *bufio.Reader, out io.Writer) ([]string, error) {
+	args := make([]string, 0)
+	for _, spec := range argSpecs(task.Args) {
+		required := slices.Contains(task.Args.Required, spec.Name)
+
+		details := []string{string(spec.Type)}
+		if len(spec.Choices) > 0 {
+			details = append(details, "one of "+strings.Join(spec.Choices, ", "))
+		}
+		switch {
+		case spec.HasDefault:
+			details = append(details, fmt.Sprintf("default %q", spec.Default))
+		case spec.Type == models.ArgTypeBool:
+			details = append(details, "default false")
+		case required:
+			details = append(details, "required")
+		default:
+			details = append(details, "optional")
+		}
+
+		for {
+			fmt.Fprintf(out, "%s (%s): ", spec.Name, strings.Join(details, ", "))
+			line, err := in.ReadString('\n')
+			if err != nil && line == "" {
+				fmt.Fprintln(out)
+				return nil, ErrPickCancelled
+			}
+			value := strings.TrimSpace(line)
+
+			if value == "" {
+				if required && !spec.HasDefault {
+					fmt.Fprintf(out, "  argument %q is required\n", spec.Name)
+					continue
+				}
+				break
+			}
+			normalized, err := checkArgValue(spec, value)
+			if err != nil {
+				fmt.Fprintf(out, "  %v\n", err)
+				continue
+			}
+			args = append(args, fmt.Sprintf("--%s=%s", spec.Name, normalized))
+			break
+		}
+	}
+	return args, nil
+}
@@ -0,0 +1,42 @@
+//go:build windows
+
+package runner
+
+import (
+	"os"
+	"os/exec"
+)
+
+func setProcessGroup(cmd *exec.Cmd) {}
+
+func interruptOnCancel(cmd *exec.Cmd) {}
+
+// terminateProcess stops the command. Windows has no signal to ask a
+// process to exit, so it is killed.
+func terminateProcess(cmd *exec.Cmd) error {
+	return cmd.Process.Kill()
+}
+
+// processAlive reports whether a process with the given PID exists.
+func processAlive(pid int) bool {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	process.Release()
+	return true
+}
+
+// stopProcessGroup stops the process with the given PID.
+func stopProcessGroup(pid int) error {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return err
+	}
+	return process.Kill()
+}
+
+// stopProcess stops the process with the given PID.
+func stopProcess(pid int) error {
+	return stopProcessGroup(pid)
+}
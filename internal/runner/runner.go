@@ -12,24 +12,29 @@ import (
 )
 
 type Runner struct {
-	Config             *config.Config
-	completed          map[string]bool
-	running            map[string]bool
-	mu                 sync.Mutex
-	DryRun             bool
-	Force              bool
+	Config    *config.Config
+	completed map[string]bool
+	running   map[string]bool
+	mu        sync.Mutex
+	DryRun    bool
+	Force     bool
+	// ChangedFiles limits the run to tasks affected by these files when not
+	// nil. Other tasks are skipped.
+	ChangedFiles       []string
 	log                *logger.Logger
 	shell              *Shell
 	executor           *Executor
 	dependencyRunner   *DependencyRunner
 	hookExecutor       *HookExecutor
 	conditionEvaluator *ConditionEvaluator
+	vars               *DynamicVars
 }
 
 func NewRunner(cfg *config.Config) *Runner {
 	log := logger.New()
 	shell := NewShell(cfg.Globals)
 	executor := NewExecutor(shell, log, false)
+	vars := NewDynamicVars(cfg.DynamicVars, cfg.DotEnv, shell)
 
 	r := &Runner{
 		Config:    cfg,
@@ -38,11 +43,12 @@ func NewRunner(cfg *config.Config) *Runner {
 		log:       log,
 		shell:     shell,
 		executor:  executor,
+		vars:      vars,
 	}
 
 	r.dependencyRunner = NewDependencyRunner(r.RunTask, log)
 	r.dependencyRunner.SetContextRunner(r.RunTaskWithContext)
-	r.hookExecutor = NewHookExecutor(cfg.Hooks, executor, log)
+	r.hookExecutor = NewHookExecutor(cfg.Hooks, cfg.DotEnv, cfg.Dir, vars, executor, log)
 	r.conditionEvaluator = NewConditionEvaluator()
 
 	return r
@@ -53,6 +59,39 @@ func (r *Runner) Reset() {
 	defer r.mu.Unlock()
 	r.completed = make(map[string]bool)
 	r.running = make(map[string]bool)
+	r.vars.Reset()
+}
+
+// invalidate forgets that the named tasks have completed, so that the next
+// run executes them again while other completed tasks are skipped. Services
+// among them are stopped so that they restart.
+func (r *Runner) invalidate(names map[string]bool) {
+	services := make([]string, 0)
+	r.mu.Lock()
+	for name := range names {
+		delete(r.completed, name)
+		if task, exists := r.Config.Tasks[name]; exists && task.Service {
+			services = append(services, name)
+		}
+	}
+	r.running = make(map[string]bool)
+	r.vars.Reset()
+	r.mu.Unlock()
+
+	if len(services) > 0 {
+		r.executor.StopServices(services...)
+	}
+}
+
+// StopServices stops every service started by the runner. It is called once
+// the run that needed them is over.
+func (r *Runner) StopServices() {
+	r.executor.StopServices()
+}
+
+// WaitService blocks until the named service exits or ctx is done.
+func (r *Runner) WaitService(ctx context.Context, name string) error {
+	return r.executor.services.wait(ctx, name)
 }
 
 func (r *Runner) validateAndSetArgs(task *models.Task, extraArgs []string) error {
@@ -62,21 +101,13 @@ func (r *Runner) validateAndSetArgs(task *models.Task, extraArgs []string) error
 		return nil
 	}
 
-	// Check if we have enough required arguments
-	requiredCount := len(task.Args.Required)
-	if len(extraArgs) < requiredCount {
-		return fmt.Errorf("task %q requires %d argument(s) but got %d\nRequired: %v",
-			task.Name, requiredCount, len(extraArgs), task.Args.Required)
-	}
-
-	// Check if we have too many arguments
-	totalExpected := len(task.Args.Required) + len(task.Args.Optional)
-	if len(extraArgs) > totalExpected {
-		return fmt.Errorf("task %q expects at most %d argument(s) but got %d\nExpected: required=%v, optional=%v",
-			task.Name, totalExpected, len(extraArgs), task.Args.Required, task.Args.Optional)
+	positional, values, err := parseTaskArgs(task, extraArgs)
+	if err != nil {
+		return err
 	}
 
-	task.ExtraArgs = extraArgs
+	task.ExtraArgs = positional
+	task.ArgValues = values
 	return nil
 }
 
@@ -90,7 +121,7 @@ func (r *Runner) RunTaskWithContext(ctx context.Context, task models.Task, extra
 	}
 
 	r.mu.Lock()
-	if r.completed[task.Name] {
+	if r.completed[task.Name] && !r.serviceStopped(task) {
 		r.mu.Unlock()
 		return nil
 	}
@@ -101,6 +132,16 @@ func (r *Runner) RunTaskWithContext(ctx context.Context, task models.Task, extra
 	r.running[task.Name] = true
 	r.mu.Unlock()
 
+	// A task kept running by a runner sharing the services, such as another
+	// process of a group, is used as it is.
+	if r.executor.services.running(task.Name) {
+		r.mu.Lock()
+		r.completed[task.Name] = true
+		delete(r.running, task.Name)
+		r.mu.Unlock()
+		return nil
+	}
+
 	if task.When != "" {
 		shouldRun, err := r.conditionEvaluator.Evaluate(task.When)
 		if err != nil {
@@ -130,11 +171,31 @@ func (r *Runner) RunTaskWithContext(ctx context.Context, task models.Task, extra
 		}
 	}
 
+	if r.ChangedFiles != nil {
+		affected, err := r.isAffected(task.Name, make(map[string]bool))
+		if err != nil {
+			return fmt.Errorf("failed to check changes for task %q: %v", task.Name, err)
+		}
+		if !affected {
+			r.log.Info("Skipping task %q (not affected by changes)", task.Name)
+			r.mu.Lock()
+			r.completed[task.Name] = true
+			delete(r.running, task.Name)
+			r.mu.Unlock()
+			return nil
+		}
+	}
+
+	usedVars := r.vars.Referenced(taskStrings(task)...)
+	task, err := r.vars.ExpandTask(task)
+	if err != nil {
+		return fmt.Errorf("task %q: %v", task.Name, err)
+	}
+
 	needsRun := true
-	if r.Force {
+	if r.Force || task.Service {
 		needsRun = true
 	} else {
-		var err error
 		needsRun, err = r.needsRerun(task.Name)
 		if err != nil {
 			return fmt.Errorf("failed to check cache for task %q: %v", task.Name, err)
@@ -152,21 +213,35 @@ func (r *Runner) RunTaskWithContext(ctx context.Context, task models.Task, extra
 		}
 	}
 
+	env, err := layerEnv(r.Config.DotEnv, r.Config.Dir, task.EnvFiles, task.Env)
+	if err != nil {
+		return fmt.Errorf("failed to load env files for task %q: %v", task.Name, err)
+	}
+	task.Env = env
+
 	if r.DryRun {
-		cmdStr := interpolateArgs(task.Command, task.ExtraArgs, &task)
+		for _, name := range usedVars {
+			value, _ := r.vars.Value(name)
+			r.log.Info("[DRY RUN] Variable %s = %q", name, value)
+		}
+		cmdStr := interpolateArgs(task.Command, task.ExtraArgs, &task, r.shell.Quoting())
 		if len(task.ExtraArgs) > 0 && cmdStr == task.Command {
 			// Arguments provided but not used in command
-			r.log.Warning("[DRY RUN] Extra arguments provided but command has no placeholders ($@, $1, $2, etc.): %v", task.ExtraArgs)
+			r.log.Warning("[DRY RUN] Extra arguments provided but command has no placeholders ($@, $1, ${name}, etc.): %v", task.ExtraArgs)
+		}
+		if task.Service {
+			r.log.Info("[DRY RUN] Would start service %q: %s", task.Name, cmdStr)
+		} else {
+			r.log.Info("[DRY RUN] Would execute task %q: %s", task.Name, cmdStr)
 		}
-		r.log.Debug("[DRY RUN] Would execute task %q: %s", task.Name, cmdStr)
 		if len(task.Requires) > 0 {
-			r.log.Debug("[DRY RUN] Would run before hooks: %v", task.Requires)
+			r.log.Info("[DRY RUN] Would run before hooks: %v", task.Requires)
 		}
 		if len(task.Triggers) > 0 {
-			r.log.Debug("[DRY RUN] Would run after hooks: %v", task.Triggers)
+			r.log.Info("[DRY RUN] Would run after hooks: %v", task.Triggers)
 		}
 		if len(task.OnSuccess) > 0 {
-			r.log.Debug("[DRY RUN] Would run on_success hooks: %v", task.OnSuccess)
+			r.log.Info("[DRY RUN] Would run on_success hooks: %v", task.OnSuccess)
 		}
 		r.mu.Lock()
 		r.completed[task.Name] = true
@@ -177,6 +252,10 @@ func (r *Runner) RunTaskWithContext(ctx context.Context, task models.Task, extra
 
 	r.executor.DryRun = r.DryRun
 
+	if task.Service {
+		return r.startService(ctx, task)
+	}
+
 	var execErr error
 	for attempt := 0; attempt <= task.Retry; attempt++ {
 		if attempt > 0 {
@@ -233,3 +312,52 @@ func (r *Runner) RunTaskWithContext(ctx context.Context, task models.Task, extra
 
 	return nil
 }
+
+// serviceStopped reports whether the task is a service that was started but
+// is no longer running, for instance because another runner sharing the
+// services stopped it.
+func (r *Runner) serviceStopped(task models.Task) bool {
+	return task.Service && !r.DryRun && !r.executor.services.running(task.Name)
+}
+
+// startService starts a service task and marks it completed once it is
+// ready, so that its dependents can run while it keeps running. A service
+// already started by a runner sharing the services is used as it is.
+func (r *Runner) startService(ctx context.Context, task models.Task) error {
+	unlock := r.executor.services.lockStart(task.Name)
+	defer unlock()
+
+	if !r.executor.services.running(task.Name) {
+		if err := r.launchService(ctx, task); err != nil {
+			return err
+		}
+	}
+
+	r.mu.Lock()
+	r.completed[task.Name] = true
+	r.running[task.Name] = false
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *Runner) launchService(ctx context.Context, task models.Task) error {
+	hooks := func(hooks []string) error {
+		return r.hookExecutor.ExecuteHooks(hooks)
+	}
+
+	if err := r.executor.StartServiceWithContext(ctx, task.Name, &task, hooks, hooks); err != nil {
+		if len(task.OnFailure) > 0 {
+			if hookErr := r.hookExecutor.ExecuteHooks(task.OnFailure); hookErr != nil && !task.Silent {
+				r.log.Warning("failure hook execution failed: %v", hookErr)
+			}
+		}
+		return err
+	}
+
+	if len(task.OnSuccess) > 0 {
+		if err := r.hookExecutor.ExecuteHooks(task.OnSuccess); err != nil && !task.Silent {
+			r.log.Warning("success hook execution failed: %v", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,416 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/azuyamat/pace/internal/models"
+)
+
+// serviceStopTimeout is how long a service may take to exit after being
+// asked to stop before it is killed.
+const serviceStopTimeout = 5 * time.Second
+
+// service is a task process that keeps running in the background.
+type service struct {
+	name   string
+	cmd    *exec.Cmd
+	kill   context.CancelFunc
+	done   chan struct{}
+	err    error
+	logged *logProbe
+}
+
+func (s *service) exited() bool {
+	select {
+	case <-s.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// serviceSet tracks the services started during a run so that they can be
+// stopped when it ends. Runners sharing a set start each service once.
+type serviceSet struct {
+	mu       sync.Mutex
+	services map[string]*service
+	order    []string
+	starting map[string]*sync.Mutex
+}
+
+func newServiceSet() *serviceSet {
+	return &serviceSet{
+		services: make(map[string]*service),
+		starting: make(map[string]*sync.Mutex),
+	}
+}
+
+// lockStart serializes the starts of the named service and returns the
+// function releasing it. A runner waiting for another to start the service
+// finds it running, and ready, once it gets the lock.
+func (ss *serviceSet) lockStart(name string) func() {
+	ss.mu.Lock()
+	lock, exists := ss.starting[name]
+	if !exists {
+		lock = &sync.Mutex{}
+		ss.starting[name] = lock
+	}
+	ss.mu.Unlock()
+
+	lock.Lock()
+	return lock.Unlock
+}
+
+// running reports whether the named service was started and has not exited.
+func (ss *serviceSet) running(name string) bool {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	svc, exists := ss.services[name]
+	return exists && !svc.exited()
+}
+
+// pid returns the process ID of the named service, or 0 if it is unknown.
+func (ss *serviceSet) pid(name string) int {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	if svc, exists := ss.services[name]; exists {
+		return svc.cmd.Process.Pid
+	}
+	return 0
+}
+
+func (ss *serviceSet) add(svc *service) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	if _, exists := ss.services[svc.name]; !exists {
+		ss.order = append(ss.order, svc.name)
+	}
+	ss.services[svc.name] = svc
+}
+
+// take removes the named services, or every service when no name is given,
+// and returns them in reverse start order.
+func (ss *serviceSet) take(names ...string) []*service {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	wanted := make(map[string]bool, len(names))
+	for _, name := range names {
+		wanted[name] = true
+	}
+
+	taken := make([]*service, 0)
+	kept := make([]string, 0, len(ss.order))
+	for i := len(ss.order) - 1; i >= 0; i-- {
+		name := ss.order[i]
+		if len(names) > 0 && !wanted[name] {
+			kept = append([]string{name}, kept...)
+			continue
+		}
+		taken = append(taken, ss.services[name])
+		delete(ss.services, name)
+	}
+	ss.order = kept
+	return taken
+}
+
+// wait blocks until the named service exits or ctx is done.
+func (ss *serviceSet) wait(ctx context.Context, name string) error {
+	ss.mu.Lock()
+	svc, exists := ss.services[name]
+	ss.mu.Unlock()
+	if !exists {
+		return nil
+	}
+
+	select {
+	case <-svc.done:
+		if svc.err != nil {
+			return fmt.Errorf("service %q exited: %v", name, svc.err)
+		}
+		return nil
+	case <-ctx.Done():
+		return nil
+	}
+}
+
+// StartServiceWithContext starts a service task in the background and waits
+// until its ready checks pass. The service keeps running after ctx is done;
+// it is only stopped by StopServices.
+func (e *Executor) StartServiceWithContext(ctx context.Context, taskName string, task *models.Task, beforeHooks, afterHooks func([]string) error) error {
+	if len(task.Requires) > 0 {
+		if err := beforeHooks(task.Requires); err != nil {
+			return err
+		}
+	}
+
+	if ctx.Err() != nil {
+		return fmt.Errorf("service %q was cancelled", taskName)
+	}
+
+	if !task.Silent {
+		e.log.Task("Starting service %q...", taskName)
+	}
+
+	shell, shellArgs := e.shell.GetShellCommand()
+	commandStr := interpolateArgs(task.Command, task.ExtraArgs, task, e.shell.Quoting())
+	cmdArgs := append(shellArgs, commandStr)
+
+	// The service outlives ctx; its own context is only cancelled to kill it.
+	serviceCtx, kill := context.WithCancel(context.Background())
+	cmd := exec.CommandContext(serviceCtx, shell, cmdArgs...)
+	cmd.Dir = task.WorkingDir
+	cmd.WaitDelay = time.Second
+	setProcessGroup(cmd)
+	cmd.Env = os.Environ()
+	for key, value := range task.Env {
+		cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%s", key, value))
+	}
+
+	svc := &service{name: taskName, cmd: cmd, kill: kill, done: make(chan struct{})}
+
+	var stdout, stderr io.Writer = io.Discard, io.Discard
+	var closers []io.Closer
+	if !task.Silent {
+		stdoutWriter := NewPrefixedWriter(taskName, true)
+		stderrWriter := NewPrefixedWriter(taskName, false)
+		stdout, stderr = stdoutWriter, stderrWriter
+		closers = append(closers, stdoutWriter, stderrWriter)
+	}
+	if task.Ready != nil && task.Ready.Log != "" {
+		svc.logged = newLogProbe(task.Ready.Log)
+		stdout = io.MultiWriter(stdout, svc.logged.writer())
+		stderr = io.MultiWriter(stderr, svc.logged.writer())
+	}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	if err := cmd.Start(); err != nil {
+		kill()
+		for _, closer := range closers {
+			closer.Close()
+		}
+		return fmt.Errorf("failed to start service %q: %v", taskName, err)
+	}
+	go func() {
+		svc.err = cmd.Wait()
+		kill()
+		for _, closer := range closers {
+			closer.Close()
+		}
+		close(svc.done)
+	}()
+	e.services.add(svc)
+
+	if err := e.waitReady(ctx, svc, task); err != nil {
+		e.stopService(svc)
+		return err
+	}
+
+	if !task.Silent {
+		e.log.Success("Service %q is ready", taskName)
+	}
+
+	if len(task.Triggers) > 0 {
+		if err := afterHooks(task.Triggers); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// waitReady polls the service's ready checks until they all pass, the
+// service exits, the timeout expires or ctx is done.
+func (e *Executor) waitReady(ctx context.Context, svc *service, task *models.Task) error {
+	if task.Ready == nil {
+		return nil
+	}
+
+	timeout, err := time.ParseDuration(task.Ready.Timeout)
+	if err != nil {
+		return fmt.Errorf("invalid ready timeout %q: %v", task.Ready.Timeout, err)
+	}
+	interval, err := time.ParseDuration(task.Ready.Interval)
+	if err != nil {
+		return fmt.Errorf("invalid ready interval %q: %v", task.Ready.Interval, err)
+	}
+
+	readyCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	logged := svc.logged.matched()
+	for {
+		if e.isReady(readyCtx, svc, task, interval) {
+			return nil
+		}
+
+		select {
+		case <-svc.done:
+			if svc.err != nil {
+				return fmt.Errorf("service %q exited before becoming ready: %v", svc.name, svc.err)
+			}
+			return fmt.Errorf("service %q exited before becoming ready", svc.name)
+		case <-readyCtx.Done():
+			if ctx.Err() != nil {
+				return fmt.Errorf("service %q was cancelled", svc.name)
+			}
+			return fmt.Errorf("service %q was not ready after %s", svc.name, task.Ready.Timeout)
+		case <-ticker.C:
+		case <-logged:
+			logged = nil
+		}
+	}
+}
+
+func (e *Executor) isReady(ctx context.Context, svc *service, task *models.Task, interval time.Duration) bool {
+	ready := task.Ready
+
+	if ready.Log != "" && !svc.logged.hasMatched() {
+		return false
+	}
+
+	if ready.Port != 0 {
+		conn, err := net.DialTimeout("tcp", net.JoinHostPort("localhost", strconv.Itoa(ready.Port)), interval)
+		if err != nil {
+			return false
+		}
+		conn.Close()
+	}
+
+	if ready.HTTP != "" {
+		requestCtx, cancel := context.WithTimeout(ctx, interval)
+		defer cancel()
+		req, err := http.NewRequestWithContext(requestCtx, http.MethodGet, ready.HTTP, nil)
+		if err != nil {
+			return false
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return false
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 400 {
+			return false
+		}
+	}
+
+	if ready.Command != "" {
+		shell, shellArgs := e.shell.GetShellCommand()
+		cmd := exec.CommandContext(ctx, shell, append(shellArgs, ready.Command)...)
+		cmd.Dir = task.WorkingDir
+		cmd.Env = os.Environ()
+		for key, value := range task.Env {
+			cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%s", key, value))
+		}
+		if err := cmd.Run(); err != nil {
+			return false
+		}
+	}
+
+	return true
+}
+
+// StopServices stops the named services, or every running service when no
+// name is given, in reverse start order.
+func (e *Executor) StopServices(names ...string) {
+	for _, svc := range e.services.take(names...) {
+		e.stopService(svc)
+	}
+}
+
+func (e *Executor) stopService(svc *service) {
+	if svc.exited() {
+		return
+	}
+
+	e.log.Info("Stopping service %q...", svc.name)
+	_ = terminateProcess(svc.cmd)
+
+	select {
+	case <-svc.done:
+	case <-time.After(serviceStopTimeout):
+		e.log.Warning("service %q did not stop after %s, killing it", svc.name, serviceStopTimeout)
+		svc.kill()
+		<-svc.done
+	}
+}
+
+// logProbe records whether a service has written a line containing the text
+// its log ready check waits for.
+type logProbe struct {
+	text string
+	once sync.Once
+	seen chan struct{}
+}
+
+func newLogProbe(text string) *logProbe {
+	return &logProbe{text: text, seen: make(chan struct{})}
+}
+
+// matched returns a channel closed once the text is seen. A nil probe never
+// matches.
+func (p *logProbe) matched() <-chan struct{} {
+	if p == nil {
+		return nil
+	}
+	return p.seen
+}
+
+func (p *logProbe) hasMatched() bool {
+	select {
+	case <-p.matched():
+		return true
+	default:
+		return false
+	}
+}
+
+// writer returns a writer for one output stream. Each stream keeps its own
+// partial line so that stdout and stderr lines are not mixed.
+func (p *logProbe) writer() io.Writer {
+	return &logProbeWriter{probe: p}
+}
+
+type logProbeWriter struct {
+	probe *logProbe
+	line  []byte
+}
+
+func (w *logProbeWriter) Write(data []byte) (int, error) {
+	if w.probe.hasMatched() {
+		return len(data), nil
+	}
+
+	w.line = append(w.line, data...)
+	for {
+		end := bytes.IndexByte(w.line, '\n')
+		if end < 0 {
+			break
+		}
+		w.check(w.line[:end])
+		w.line = w.line[end+1:]
+	}
+	// Prompts are often written without a trailing newline.
+	w.check(w.line)
+	return len(data), nil
+}
+
+func (w *logProbeWriter) check(line []byte) {
+	if bytes.Contains(line, []byte(w.probe.text)) {
+		w.probe.once.Do(func() { close(w.probe.seen) })
+	}
+}
@@ -1,6 +1,7 @@
 package runner
 
 import (
+	"path"
 	"runtime"
 	"strings"
 )
@@ -29,3 +30,19 @@ func (s *Shell) GetShellCommand() (string, []string) {
 	}
 	return "sh", []string{"-c"}
 }
+
+// Quoting reports how arguments must be quoted for the configured shell.
+func (s *Shell) Quoting() QuoteStyle {
+	shell, _ := s.GetShellCommand()
+	name := strings.ToLower(path.Base(strings.ReplaceAll(shell, "\\", "/")))
+	name = strings.TrimSuffix(name, ".exe")
+
+	switch name {
+	case "powershell", "pwsh":
+		return QuotePowerShell
+	case "cmd":
+		return QuoteCmd
+	default:
+		return QuotePOSIX
+	}
+}
@@ -0,0 +1,346 @@
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/azuyamat/pace/internal/config"
+	"github.com/azuyamat/pace/internal/logger"
+	"github.com/azuyamat/pace/internal/models"
+)
+
+const (
+	// restartBackoffMin and restartBackoffMax bound the delay before a
+	// crashed process is restarted. The delay doubles after each restart and
+	// is reset once a process has stayed up for restartStableAfter.
+	restartBackoffMin  = time.Second
+	restartBackoffMax  = 30 * time.Second
+	restartStableAfter = 10 * time.Second
+
+	// groupStopTimeout is how long "pace down" waits for a supervisor to
+	// stop its processes before stopping them itself.
+	groupStopTimeout = serviceStopTimeout + 2*time.Second
+)
+
+const (
+	StatusStarting   = "starting"
+	StatusRunning    = "running"
+	StatusRestarting = "restarting"
+	StatusExited     = "exited"
+	StatusFailed     = "failed"
+)
+
+// ProcessState describes one supervised task of a group.
+type ProcessState struct {
+	Task     string    `json:"task"`
+	PID      int       `json:"pid"`
+	Status   string    `json:"status"`
+	Restarts int       `json:"restarts"`
+	Started  time.Time `json:"started"`
+}
+
+// Alive reports whether the process is still running.
+func (p ProcessState) Alive() bool {
+	return p.PID > 0 && processAlive(p.PID)
+}
+
+// GroupState is written under .pace-cache while a group is up, so that other
+// pace processes can list and stop it.
+type GroupState struct {
+	Group     string         `json:"group"`
+	PID       int            `json:"pid"`
+	Started   time.Time      `json:"started"`
+	Processes []ProcessState `json:"processes"`
+}
+
+// Alive reports whether the supervisor that wrote the state is running.
+func (s GroupState) Alive() bool {
+	return processAlive(s.PID)
+}
+
+func groupStatePath(group string) string {
+	return filepath.Join(cacheDir, "up", cacheFileName(group)+".json")
+}
+
+// ReadGroupStates returns the state of every group that is up, sorted by
+// group name.
+func ReadGroupStates() ([]GroupState, error) {
+	paths, err := filepath.Glob(filepath.Join(cacheDir, "up", "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	states := make([]GroupState, 0, len(paths))
+	for _, path := range paths {
+		state, err := readGroupState(path)
+		if err != nil {
+			return nil, err
+		}
+		states = append(states, state)
+	}
+	sort.Slice(states, func(i, j int) bool {
+		return states[i].Group < states[j].Group
+	})
+	return states, nil
+}
+
+func readGroupState(path string) (GroupState, error) {
+	var state GroupState
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return state, err
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return state, fmt.Errorf("failed to read group state %q: %v", path, err)
+	}
+	return state, nil
+}
+
+// StopGroup stops a group that was started by another pace process. The
+// supervisor is asked to stop its processes; any left running after it
+// exits, or after it crashed, are stopped directly.
+func StopGroup(state GroupState) error {
+	if state.Alive() {
+		if err := stopProcess(state.PID); err != nil {
+			return fmt.Errorf("failed to stop group %q: %v", state.Group, err)
+		}
+		deadline := time.Now().Add(groupStopTimeout)
+		for state.Alive() && time.Now().Before(deadline) {
+			time.Sleep(100 * time.Millisecond)
+		}
+	}
+
+	for _, process := range state.Processes {
+		if process.Alive() {
+			_ = stopProcessGroup(process.PID)
+		}
+	}
+
+	if err := os.Remove(groupStatePath(state.Group)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// Supervisor keeps the tasks of a group running in the foreground, restarting
+// them according to their restart policy until it is stopped. Each task has
+// its own runner so that it can be restarted while the others keep running,
+// but the runners share their services so that a dependency of several tasks
+// is started once.
+type Supervisor struct {
+	config  *config.Config
+	group   string
+	runners []*Runner
+	log     *logger.Logger
+	state   GroupState
+	mu      sync.Mutex
+}
+
+func NewSupervisor(cfg *config.Config, group string) (*Supervisor, error) {
+	members, exists := cfg.Groups[group]
+	if !exists {
+		return nil, fmt.Errorf("group %q not found", group)
+	}
+
+	s := &Supervisor{
+		config: cfg,
+		group:  group,
+		log:    logger.New(),
+		state:  GroupState{Group: group},
+	}
+	services := newServiceSet()
+	for _, name := range members {
+		runner := NewRunner(cfg)
+		runner.executor.services = services
+		s.runners = append(s.runners, runner)
+		s.state.Processes = append(s.state.Processes, ProcessState{Task: name, Status: StatusStarting})
+	}
+	return s, nil
+}
+
+// stopServices stops every process of the group and the services they
+// depend on.
+func (s *Supervisor) stopServices() {
+	if len(s.runners) > 0 {
+		s.runners[0].StopServices()
+	}
+}
+
+// Up starts every task of the group in order and supervises them until ctx
+// is done or all of them have exited. Every process is stopped on return.
+func (s *Supervisor) Up(ctx context.Context) error {
+	if existing, err := readGroupState(groupStatePath(s.group)); err == nil && existing.Alive() {
+		return fmt.Errorf("group %q is already up (pid %d), run 'pace down %s' first", s.group, existing.PID, s.group)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(groupStatePath(s.group)), 0755); err != nil {
+		return err
+	}
+	s.state.PID = os.Getpid()
+	s.state.Started = time.Now()
+	if err := s.writeState(); err != nil {
+		return err
+	}
+	defer os.Remove(groupStatePath(s.group))
+	defer s.stopServices()
+
+	for i := range s.state.Processes {
+		if err := s.start(ctx, i); err != nil {
+			return err
+		}
+	}
+	s.log.Success("Group %q is up (press Ctrl+C to stop)", s.group)
+
+	var wg sync.WaitGroup
+	for i := range s.state.Processes {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			s.supervise(ctx, i)
+		}(i)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-ctx.Done():
+		s.log.Info("\nStopping group %q...", s.group)
+		<-done
+	case <-done:
+		s.log.Info("All tasks in group %q have exited", s.group)
+	}
+	return nil
+}
+
+// start runs the task as a service, after its dependencies, and records its
+// PID once it is ready.
+func (s *Supervisor) start(ctx context.Context, i int) error {
+	name := s.state.Processes[i].Task
+	task, exists := s.config.GetTask(name)
+	if !exists {
+		return fmt.Errorf("task %q not found", name)
+	}
+	task.Service = true
+
+	runner := s.runners[i]
+	runner.invalidate(map[string]bool{name: true})
+	if err := runner.RunTaskWithContext(ctx, task); err != nil {
+		s.updateState(i, func(p *ProcessState) { p.Status = StatusFailed })
+		return err
+	}
+
+	pid := runner.executor.services.pid(name)
+	s.updateState(i, func(p *ProcessState) {
+		p.PID = pid
+		p.Status = StatusRunning
+		p.Started = time.Now()
+	})
+	return nil
+}
+
+// supervise waits for the task to exit and restarts it with an increasing
+// delay while its restart policy asks for it.
+func (s *Supervisor) supervise(ctx context.Context, i int) {
+	name := s.state.Processes[i].Task
+	policy := s.config.Tasks[name].Restart
+	backoff := restartBackoffMin
+	started := time.Now()
+
+	err := s.runners[i].WaitService(ctx, name)
+	for ctx.Err() == nil {
+		if err != nil {
+			s.log.Warning("%v", err)
+		} else {
+			s.log.Info("Task %q exited", name)
+		}
+
+		if policy != models.RestartAlways && (policy != models.RestartOnFailure || err == nil) {
+			status := StatusExited
+			if err != nil {
+				status = StatusFailed
+			}
+			s.updateState(i, func(p *ProcessState) { p.Status = status })
+			return
+		}
+
+		if time.Since(started) >= restartStableAfter {
+			backoff = restartBackoffMin
+		}
+		s.updateState(i, func(p *ProcessState) {
+			p.Status = StatusRestarting
+			p.Restarts++
+		})
+		s.log.Info("Restarting task %q in %s...", name, backoff)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, restartBackoffMax)
+
+		started = time.Now()
+		if err = s.start(ctx, i); err != nil {
+			continue
+		}
+		err = s.runners[i].WaitService(ctx, name)
+	}
+}
+
+func (s *Supervisor) updateState(i int, update func(p *ProcessState)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	update(&s.state.Processes[i])
+	if err := s.writeStateLocked(); err != nil {
+		s.log.Warning("failed to write group state: %v", err)
+	}
+}
+
+func (s *Supervisor) writeState() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.writeStateLocked()
+}
+
+func (s *Supervisor) writeStateLocked() error {
+	data, err := json.MarshalIndent(s.state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(groupStatePath(s.group), data, 0644)
+}
+
+// GroupNames returns the names of the configured groups, sorted.
+func GroupNames(cfg *config.Config) []string {
+	names := make([]string, 0, len(cfg.Groups))
+	for name := range cfg.Groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// DefaultGroup returns the group "pace up" starts when none is named: the
+// only group when exactly one is configured.
+func DefaultGroup(cfg *config.Config) (string, error) {
+	names := GroupNames(cfg)
+	switch len(names) {
+	case 0:
+		return "", fmt.Errorf("no groups defined, add one with: group dev [api, web]")
+	case 1:
+		return names[0], nil
+	default:
+		return "", fmt.Errorf("several groups are defined, choose one of: %s", strings.Join(names, ", "))
+	}
+}
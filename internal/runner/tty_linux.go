@@ -0,0 +1,10 @@
+//go:build linux
+
+package runner
+
+import "golang.org/x/sys/unix"
+
+const (
+	ioctlReadTermios  = unix.TCGETS
+	ioctlWriteTermios = unix.TCSETS
+)
@@ -0,0 +1,27 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+
+package runner
+
+import "golang.org/x/sys/unix"
+
+// enableKeyInput switches the terminal to read single unechoed keystrokes.
+// Output processing and signal keys such as Ctrl+C keep working, unlike in
+// full raw mode.
+func enableKeyInput(fd int) (func(), error) {
+	original, err := unix.IoctlGetTermios(fd, ioctlReadTermios)
+	if err != nil {
+		return nil, err
+	}
+
+	termios := *original
+	termios.Lflag &^= unix.ICANON | unix.ECHO
+	termios.Cc[unix.VMIN] = 1
+	termios.Cc[unix.VTIME] = 0
+	if err := unix.IoctlSetTermios(fd, ioctlWriteTermios, &termios); err != nil {
+		return nil, err
+	}
+
+	return func() {
+		_ = unix.IoctlSetTermios(fd, ioctlWriteTermios, original)
+	}, nil
+}
@@ -0,0 +1,237 @@
+package runner
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/azuyamat/pace/internal/config/types"
+	"github.com/azuyamat/pace/internal/models"
+)
+
+var dynamicVarPattern = regexp.MustCompile(`\$\{([^}]+)\}`)
+
+// DynamicVars evaluates variables defined as $(command) or sh("command").
+// Each variable runs at most once per run, the first time a task or hook
+// that references it is executed.
+type DynamicVars struct {
+	vars       map[string]types.DynamicVar
+	env        map[string]string
+	shell      *Shell
+	values     map[string]string
+	evaluating map[string]bool
+	mu         sync.Mutex
+}
+
+func NewDynamicVars(vars map[string]types.DynamicVar, env map[string]string, shell *Shell) *DynamicVars {
+	return &DynamicVars{
+		vars:       vars,
+		env:        env,
+		shell:      shell,
+		values:     make(map[string]string),
+		evaluating: make(map[string]bool),
+	}
+}
+
+// Reset forgets evaluated values so they are computed again on the next run.
+func (dv *DynamicVars) Reset() {
+	dv.mu.Lock()
+	defer dv.mu.Unlock()
+	dv.values = make(map[string]string)
+}
+
+// Value returns the value of an already evaluated variable.
+func (dv *DynamicVars) Value(name string) (string, bool) {
+	dv.mu.Lock()
+	defer dv.mu.Unlock()
+	value, ok := dv.values[name]
+	return value, ok
+}
+
+// Expand replaces ${name} references to dynamic variables in s.
+func (dv *DynamicVars) Expand(s string) (string, error) {
+	if len(dv.vars) == 0 || !strings.Contains(s, "${") {
+		return s, nil
+	}
+
+	dv.mu.Lock()
+	defer dv.mu.Unlock()
+	return dv.expandLocked(s)
+}
+
+func (dv *DynamicVars) expandLocked(s string) (string, error) {
+	var firstErr error
+	result := dynamicVarPattern.ReplaceAllStringFunc(s, func(match string) string {
+		name := match[2 : len(match)-1]
+		if _, ok := dv.vars[name]; !ok || firstErr != nil {
+			return match
+		}
+		value, err := dv.evaluateLocked(name)
+		if err != nil {
+			firstErr = err
+			return match
+		}
+		return value
+	})
+	return result, firstErr
+}
+
+func (dv *DynamicVars) evaluateLocked(name string) (string, error) {
+	if value, ok := dv.values[name]; ok {
+		return value, nil
+	}
+
+	v := dv.vars[name]
+	if dv.evaluating[name] {
+		return "", fmt.Errorf("variable %q (%s) references itself", name, v.Source)
+	}
+	dv.evaluating[name] = true
+	defer delete(dv.evaluating, name)
+
+	command, err := dv.expandLocked(v.Command)
+	if err != nil {
+		return "", err
+	}
+
+	shell, shellArgs := dv.shell.GetShellCommand()
+	cmd := exec.Command(shell, append(shellArgs, command)...)
+	cmd.Env = os.Environ()
+	for key, value := range dv.env {
+		cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%s", key, value))
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		message := strings.TrimSpace(stderr.String())
+		if message != "" {
+			return "", fmt.Errorf("failed to evaluate variable %q (%s): %v: %s", name, v.Source, err, message)
+		}
+		return "", fmt.Errorf("failed to evaluate variable %q (%s): %v", name, v.Source, err)
+	}
+
+	value := strings.TrimRight(stdout.String(), "\r\n")
+	dv.values[name] = value
+	return value, nil
+}
+
+// Referenced lists the dynamic variables referenced by the given strings,
+// including variables that are only used by other variables' commands.
+func (dv *DynamicVars) Referenced(values ...string) []string {
+	seen := make(map[string]bool)
+	names := make([]string, 0)
+	for len(values) > 0 {
+		value := values[0]
+		values = values[1:]
+		for _, match := range dynamicVarPattern.FindAllStringSubmatch(value, -1) {
+			name := match[1]
+			v, ok := dv.vars[name]
+			if !ok || seen[name] {
+				continue
+			}
+			seen[name] = true
+			names = append(names, name)
+			values = append(values, v.Command)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (dv *DynamicVars) ExpandTask(task models.Task) (models.Task, error) {
+	var err error
+	if task.Command, err = dv.Expand(task.Command); err != nil {
+		return task, err
+	}
+	if task.WorkingDir, err = dv.Expand(task.WorkingDir); err != nil {
+		return task, err
+	}
+	if task.Inputs, err = dv.expandSlice(task.Inputs); err != nil {
+		return task, err
+	}
+	if task.Outputs, err = dv.expandSlice(task.Outputs); err != nil {
+		return task, err
+	}
+	if task.WatchInputs, err = dv.expandSlice(task.WatchInputs); err != nil {
+		return task, err
+	}
+	if task.Env, err = dv.expandMap(task.Env); err != nil {
+		return task, err
+	}
+	if task.Ready != nil {
+		ready := *task.Ready
+		if ready.HTTP, err = dv.Expand(ready.HTTP); err != nil {
+			return task, err
+		}
+		if ready.Command, err = dv.Expand(ready.Command); err != nil {
+			return task, err
+		}
+		task.Ready = &ready
+	}
+	return task, nil
+}
+
+func (dv *DynamicVars) ExpandHook(hook models.Hook) (models.Hook, error) {
+	var err error
+	if hook.Command, err = dv.Expand(hook.Command); err != nil {
+		return hook, err
+	}
+	if hook.WorkingDir, err = dv.Expand(hook.WorkingDir); err != nil {
+		return hook, err
+	}
+	if hook.Env, err = dv.expandMap(hook.Env); err != nil {
+		return hook, err
+	}
+	return hook, nil
+}
+
+func (dv *DynamicVars) expandSlice(values []string) ([]string, error) {
+	if values == nil {
+		return nil, nil
+	}
+	result := make([]string, len(values))
+	for i, value := range values {
+		expanded, err := dv.Expand(value)
+		if err != nil {
+			return nil, err
+		}
+		result[i] = expanded
+	}
+	return result, nil
+}
+
+func (dv *DynamicVars) expandMap(values map[string]string) (map[string]string, error) {
+	if values == nil {
+		return nil, nil
+	}
+	result := make(map[string]string, len(values))
+	for key, value := range values {
+		expanded, err := dv.Expand(value)
+		if err != nil {
+			return nil, err
+		}
+		result[key] = expanded
+	}
+	return result, nil
+}
+
+// taskStrings returns every task field that may reference a variable.
+func taskStrings(task models.Task) []string {
+	values := []string{task.Command, task.WorkingDir}
+	values = append(values, task.Inputs...)
+	values = append(values, task.Outputs...)
+	values = append(values, task.WatchInputs...)
+	if task.Ready != nil {
+		values = append(values, task.Ready.HTTP, task.Ready.Command)
+	}
+	for _, value := range task.Env {
+		values = append(values, value)
+	}
+	return values
+}
@@ -0,0 +1,146 @@
+package runner
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/azuyamat/pace/internal/logger"
+	"github.com/fsnotify/fsnotify"
+)
+
+type fileOp int
+
+const (
+	fileCreated fileOp = iota
+	fileWritten
+	fileRemoved
+)
+
+func (op fileOp) String() string {
+	switch op {
+	case fileCreated:
+		return "CREATE"
+	case fileWritten:
+		return "WRITE"
+	default:
+		return "REMOVE"
+	}
+}
+
+type fileEvent struct {
+	Path string
+	Op   fileOp
+}
+
+// watchBackend reports changes to files within a watch scope. The Watcher
+// only consumes events, so backends can be swapped or driven by hand.
+type watchBackend interface {
+	Events() <-chan fileEvent
+	Errors() <-chan error
+	Close() error
+}
+
+// fsnotifyBackend reports changes using native file system notifications.
+type fsnotifyBackend struct {
+	watcher *fsnotify.Watcher
+	tree    *watchTree
+	events  chan fileEvent
+	errors  chan error
+	done    chan struct{}
+}
+
+func newFSNotifyBackend(scope *watchScope, log *logger.Logger) (*fsnotifyBackend, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create watcher: %v", err)
+	}
+
+	b := &fsnotifyBackend{
+		watcher: watcher,
+		tree:    newWatchTree(watcher, scope, log),
+		events:  make(chan fileEvent),
+		errors:  make(chan error),
+		done:    make(chan struct{}),
+	}
+
+	b.tree.addRoots()
+	if len(b.tree.dirs) == 0 {
+		watcher.Close()
+		return nil, fmt.Errorf("no valid paths to watch")
+	}
+	log.Debug("Watching %d directories", len(b.tree.dirs))
+
+	go b.loop()
+	return b, nil
+}
+
+func (b *fsnotifyBackend) Events() <-chan fileEvent { return b.events }
+func (b *fsnotifyBackend) Errors() <-chan error     { return b.errors }
+
+func (b *fsnotifyBackend) Close() error {
+	close(b.done)
+	return b.watcher.Close()
+}
+
+func (b *fsnotifyBackend) loop() {
+	for {
+		select {
+		case <-b.done:
+			return
+
+		case event, ok := <-b.watcher.Events:
+			if !ok {
+				return
+			}
+			b.handleEvent(event)
+
+		case err, ok := <-b.watcher.Errors:
+			if !ok {
+				return
+			}
+			select {
+			case b.errors <- err:
+			case <-b.done:
+				return
+			}
+		}
+	}
+}
+
+func (b *fsnotifyBackend) handleEvent(event fsnotify.Event) {
+	var op fileOp
+	switch {
+	case event.Op&fsnotify.Create != 0:
+		op = fileCreated
+	case event.Op&fsnotify.Write != 0:
+		op = fileWritten
+	case event.Op&(fsnotify.Remove|fsnotify.Rename) != 0:
+		op = fileRemoved
+	default:
+		return
+	}
+
+	// Files may be written to a new directory before it is watched, so the
+	// files already in it are reported as created.
+	added := b.tree.handleEvent(event)
+	b.send(fileEvent{Path: event.Name, Op: op})
+	for _, dir := range added {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			if !entry.IsDir() {
+				b.send(fileEvent{Path: filepath.Join(dir, entry.Name()), Op: fileCreated})
+			}
+		}
+	}
+}
+
+func (b *fsnotifyBackend) send(event fileEvent) {
+	select {
+	case b.events <- event:
+	case <-b.done:
+	}
+}
@@ -0,0 +1,117 @@
+package runner
+
+import (
+	"sort"
+
+	"github.com/azuyamat/pace/internal/config"
+	"github.com/azuyamat/pace/internal/models"
+)
+
+// taskGraph is the DependsOn graph below a watched task. It maps a changed
+// file to the tasks whose inputs match it, so that only those tasks and the
+// tasks depending on them are run again.
+type taskGraph struct {
+	root       string
+	patterns   map[string][]string
+	dependents map[string][]string
+}
+
+func newTaskGraph(cfg *config.Config, root models.Task, rootPatterns []string) *taskGraph {
+	g := &taskGraph{
+		root:       root.Name,
+		patterns:   map[string][]string{root.Name: rootPatterns},
+		dependents: make(map[string][]string),
+	}
+
+	visited := map[string]bool{root.Name: true}
+	queue := []models.Task{root}
+	for len(queue) > 0 {
+		task := queue[0]
+		queue = queue[1:]
+		for _, depName := range task.DependsOn {
+			g.dependents[depName] = append(g.dependents[depName], task.Name)
+			if visited[depName] {
+				continue
+			}
+			visited[depName] = true
+			dep, exists := cfg.Tasks[depName]
+			if !exists {
+				continue
+			}
+			g.patterns[depName] = watchPatterns(dep)
+			queue = append(queue, dep)
+		}
+	}
+
+	return g
+}
+
+// watchPatterns returns the patterns that trigger a task in watch mode.
+func watchPatterns(task models.Task) []string {
+	if len(task.WatchInputs) > 0 {
+		return task.WatchInputs
+	}
+	return task.Inputs
+}
+
+// allPatterns returns the patterns of every task in the graph.
+func (g *taskGraph) allPatterns() []string {
+	patterns := make([]string, 0)
+	for _, name := range g.names() {
+		patterns = append(patterns, g.patterns[name]...)
+	}
+	return patterns
+}
+
+// matching returns the tasks whose patterns match path.
+func (g *taskGraph) matching(path string) []string {
+	matched := make([]string, 0)
+	for _, name := range g.names() {
+		for _, pattern := range g.patterns[name] {
+			if matchesGlobPattern(pattern, path) {
+				matched = append(matched, name)
+				break
+			}
+		}
+	}
+	return matched
+}
+
+// affected returns the changed tasks together with every task that depends
+// on them, directly or transitively.
+func (g *taskGraph) affected(changed map[string]bool) map[string]bool {
+	result := make(map[string]bool)
+	queue := make([]string, 0, len(changed))
+	for name := range changed {
+		queue = append(queue, name)
+	}
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		if result[name] {
+			continue
+		}
+		result[name] = true
+		queue = append(queue, g.dependents[name]...)
+	}
+	return result
+}
+
+func (g *taskGraph) names() []string {
+	names := make([]string, 0, len(g.patterns))
+	for name := range g.patterns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// sortedNames returns the names in a set in sorted order.
+func sortedNames(set map[string]bool) []string {
+	names := make([]string, 0, len(set))
+	for name := range set {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,146 @@
+package runner
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// DefaultPollInterval is used when polling is enabled without an interval.
+const DefaultPollInterval = time.Second
+
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// pollBackend reports changes by periodically comparing the modification
+// time and size of every matching file. It works where file system
+// notifications are not delivered, such as network shares and container
+// volumes.
+type pollBackend struct {
+	scope    *watchScope
+	snapshot map[string]fileState
+	events   chan fileEvent
+	errors   chan error
+	done     chan struct{}
+}
+
+func newPollBackend(scope *watchScope, interval time.Duration) *pollBackend {
+	b := &pollBackend{
+		scope:  scope,
+		events: make(chan fileEvent),
+		errors: make(chan error),
+		done:   make(chan struct{}),
+	}
+	b.snapshot = b.scan()
+
+	go b.loop(interval)
+	return b
+}
+
+func (b *pollBackend) Events() <-chan fileEvent { return b.events }
+func (b *pollBackend) Errors() <-chan error     { return b.errors }
+
+func (b *pollBackend) Close() error {
+	close(b.done)
+	return nil
+}
+
+func (b *pollBackend) loop(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-b.done:
+			return
+		case <-ticker.C:
+			for _, event := range b.poll() {
+				select {
+				case b.events <- event:
+				case <-b.done:
+					return
+				}
+			}
+		}
+	}
+}
+
+// poll rescans the scope and returns the changes since the previous scan,
+// sorted by path.
+func (b *pollBackend) poll() []fileEvent {
+	current := b.scan()
+	events := make([]fileEvent, 0)
+
+	for path, state := range current {
+		previous, existed := b.snapshot[path]
+		switch {
+		case !existed:
+			events = append(events, fileEvent{Path: path, Op: fileCreated})
+		case !previous.modTime.Equal(state.modTime) || previous.size != state.size:
+			events = append(events, fileEvent{Path: path, Op: fileWritten})
+		}
+	}
+	for path := range b.snapshot {
+		if _, exists := current[path]; !exists {
+			events = append(events, fileEvent{Path: path, Op: fileRemoved})
+		}
+	}
+
+	b.snapshot = current
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].Path < events[j].Path
+	})
+	return events
+}
+
+func (b *pollBackend) scan() map[string]fileState {
+	files := make(map[string]fileState)
+
+	for _, root := range b.scope.existingRoots() {
+		_ = filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			if entry.IsDir() {
+				if path != root && !b.scope.wants(path) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !b.scope.matches(path) {
+				return nil
+			}
+			info, err := entry.Info()
+			if err != nil {
+				return nil
+			}
+			files[path] = fileState{modTime: info.ModTime(), size: info.Size()}
+			return nil
+		})
+	}
+
+	return files
+}
+
+// ParsePollInterval reads a --poll flag or watch_poll global value. "true"
+// selects the default interval and "" or "false" disables polling.
+func ParsePollInterval(value string) (time.Duration, error) {
+	switch value {
+	case "", "false":
+		return 0, nil
+	case "true":
+		return DefaultPollInterval, nil
+	}
+	interval, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, err
+	}
+	if interval <= 0 {
+		return 0, fmt.Errorf("poll interval must be positive")
+	}
+	return interval, nil
+}
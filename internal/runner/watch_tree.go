@@ -0,0 +1,207 @@
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/azuyamat/pace/internal/logger"
+	"github.com/fsnotify/fsnotify"
+)
+
+// defaultWatchIgnores lists directories that are never watched below a
+// pattern's root. More can be added with the watch_ignore global.
+var defaultWatchIgnores = []string{".git", ".hg", ".svn", ".pace-cache", "node_modules"}
+
+type watchRoot struct {
+	dir       string
+	recursive bool
+}
+
+// watchScope describes the directories that can contain files matching a set
+// of patterns. Each pattern is rooted at its static prefix, recursively when
+// the pattern can match nested paths.
+type watchScope struct {
+	patterns []string
+	roots    []watchRoot
+	ignore   []string
+}
+
+func newWatchScope(patterns []string, ignore []string) *watchScope {
+	s := &watchScope{patterns: patterns, ignore: ignore}
+	seen := make(map[watchRoot]bool)
+	for _, pattern := range patterns {
+		dir, recursive := globStaticPrefix(pattern)
+		root := watchRoot{dir: dir, recursive: recursive}
+		if !seen[root] {
+			seen[root] = true
+			s.roots = append(s.roots, root)
+		}
+	}
+	return s
+}
+
+// watchIgnores returns the directory ignore rules for the given globals.
+func watchIgnores(globals map[string]string) []string {
+	ignore := append([]string{}, defaultWatchIgnores...)
+	for _, rule := range strings.FieldsFunc(globals["watch_ignore"], func(r rune) bool {
+		return r == ',' || r == ' '
+	}) {
+		ignore = append(ignore, strings.TrimSuffix(filepath.ToSlash(rule), "/"))
+	}
+	return ignore
+}
+
+// wants reports whether dir may contain matching files or lead to a root
+// that does not exist yet.
+func (s *watchScope) wants(dir string) bool {
+	for _, root := range s.roots {
+		if dir == root.dir || isUnderDir(root.dir, dir) {
+			return true
+		}
+		if root.recursive && isUnderDir(dir, root.dir) && !s.isIgnored(dir, root.dir) {
+			return true
+		}
+	}
+	return false
+}
+
+// matches reports whether path matches any of the scope's patterns.
+func (s *watchScope) matches(path string) bool {
+	for _, pattern := range s.patterns {
+		if matchesGlobPattern(pattern, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// isIgnored reports whether any directory between root and dir matches an
+// ignore rule.
+func (s *watchScope) isIgnored(dir, root string) bool {
+	rel, err := filepath.Rel(root, dir)
+	if err != nil {
+		return false
+	}
+	rel = filepath.ToSlash(rel)
+	parts := strings.Split(rel, "/")
+	for i, part := range parts {
+		for _, rule := range s.ignore {
+			if matchesGlobPattern(rule, part) || matchesGlobPattern(rule, strings.Join(parts[:i+1], "/")) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// existingRoots returns each root, or its closest existing ancestor when the
+// root does not exist yet so that it is picked up once created.
+func (s *watchScope) existingRoots() []string {
+	dirs := make([]string, 0, len(s.roots))
+	for _, root := range s.roots {
+		dir := root.dir
+		for {
+			if info, err := os.Stat(dir); err == nil && info.IsDir() {
+				break
+			}
+			parent := filepath.Dir(dir)
+			if parent == dir {
+				break
+			}
+			dir = parent
+		}
+		dirs = append(dirs, dir)
+	}
+	return dirs
+}
+
+// watchTree keeps an fsnotify watcher subscribed to every directory of a
+// scope, adding directories as they are created and dropping removed ones.
+type watchTree struct {
+	*watchScope
+	watcher *fsnotify.Watcher
+	dirs    map[string]bool
+	log     *logger.Logger
+}
+
+func newWatchTree(watcher *fsnotify.Watcher, scope *watchScope, log *logger.Logger) *watchTree {
+	return &watchTree{
+		watchScope: scope,
+		watcher:    watcher,
+		dirs:       make(map[string]bool),
+		log:        log,
+	}
+}
+
+func (t *watchTree) addRoots() {
+	for _, dir := range t.existingRoots() {
+		t.add(dir)
+	}
+}
+
+// add watches dir if it is wanted and descends into the subdirectories that
+// are. It returns the directories that were newly added.
+func (t *watchTree) add(dir string) []string {
+	dir = filepath.Clean(dir)
+	if t.dirs[dir] || !t.wants(dir) {
+		return nil
+	}
+
+	if err := t.watcher.Add(dir); err != nil {
+		t.log.Warning("failed to watch directory %q: %v", dir, err)
+		return nil
+	}
+	t.dirs[dir] = true
+	t.log.Debug("Watching directory: %s", dir)
+	added := []string{dir}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return added
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			added = append(added, t.add(filepath.Join(dir, entry.Name()))...)
+		}
+	}
+	return added
+}
+
+// remove stops tracking dir and everything below it.
+func (t *watchTree) remove(dir string) {
+	dir = filepath.Clean(dir)
+	for watched := range t.dirs {
+		if watched == dir || isUnderDir(watched, dir) {
+			delete(t.dirs, watched)
+			_ = t.watcher.Remove(watched)
+			t.log.Debug("Stopped watching directory: %s", watched)
+		}
+	}
+}
+
+// handleEvent keeps the watched set in sync with created and removed
+// directories. It returns the directories added by the event.
+func (t *watchTree) handleEvent(event fsnotify.Event) []string {
+	if event.Op&fsnotify.Create != 0 {
+		if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+			return t.add(event.Name)
+		}
+	}
+	if event.Op&(fsnotify.Remove|fsnotify.Rename) != 0 && t.dirs[filepath.Clean(event.Name)] {
+		t.remove(event.Name)
+	}
+	return nil
+}
+
+// isUnderDir reports whether path lies strictly inside dir.
+func isUnderDir(path, dir string) bool {
+	if path == dir {
+		return false
+	}
+	rel, err := filepath.Rel(dir, path)
+	if err != nil || filepath.IsAbs(rel) {
+		return false
+	}
+	return rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
@@ -5,196 +5,383 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
-	"path/filepath"
+	"strings"
 	"sync"
 	"syscall"
 	"time"
 
+	"github.com/azuyamat/pace/internal/config"
 	"github.com/azuyamat/pace/internal/logger"
 	"github.com/azuyamat/pace/internal/models"
-	"github.com/fsnotify/fsnotify"
 )
 
-type Watcher struct {
+const clearScreen = "\033[H\033[2J"
+
+// WatchTarget is a task watched for changes to the files matching Patterns.
+type WatchTarget struct {
+	Task      models.Task
+	Patterns  []string
+	ExtraArgs []string
+	Options   models.WatchOptions
+}
+
+// watchedTask holds the run state of one target. Each target has its own
+// runner so that it can be restarted without affecting the others, but the
+// runners share their services so that a service several targets depend on
+// is started once.
+type watchedTask struct {
+	WatchTarget
 	runner     *Runner
-	task       models.Task
-	patterns   []string
-	extraArgs  []string
-	log        *logger.Logger
+	graph      *taskGraph
+	changed    map[string]bool
+	debounce   *time.Timer
 	cancelFunc context.CancelFunc
-	taskMu     sync.Mutex
+	generation int
+	force      bool
+	runMu      sync.Mutex
+	mu         sync.Mutex
+}
+
+// Watcher runs one or more tasks and re-runs each of them when one of its
+// inputs changes. All tasks share a single watch backend.
+type Watcher struct {
+	config *config.Config
+	tasks  []*watchedTask
+	log    *logger.Logger
+	// PollInterval selects the polling backend when non-zero.
+	PollInterval time.Duration
+	pending      chan *watchedTask
+	// done is closed on shutdown, once pending is no longer read.
+	done   chan struct{}
+	paused bool
 }
 
-func NewWatcher(runner *Runner, task models.Task, patterns []string, extraArgs []string) *Watcher {
-	return &Watcher{
-		runner:    runner,
-		task:      task,
-		patterns:  patterns,
-		extraArgs: extraArgs,
-		log:       logger.New(),
+func NewWatcher(cfg *config.Config, targets []WatchTarget) *Watcher {
+	w := &Watcher{
+		config:  cfg,
+		log:     logger.New(),
+		pending: make(chan *watchedTask, len(targets)),
+		done:    make(chan struct{}),
+	}
+	services := newServiceSet()
+	for _, target := range targets {
+		runner := NewRunner(cfg)
+		runner.executor.services = services
+		w.tasks = append(w.tasks, &watchedTask{
+			WatchTarget: target,
+			runner:      runner,
+			graph:       newTaskGraph(cfg, target.Task, target.Patterns),
+			changed:     make(map[string]bool),
+		})
+	}
+	return w
+}
+
+// stopServices stops the services started by any of the watched tasks.
+func (w *Watcher) stopServices() {
+	if len(w.tasks) > 0 {
+		w.tasks[0].runner.StopServices()
 	}
 }
 
 func (w *Watcher) Watch() error {
-	watcher, err := fsnotify.NewWatcher()
-	if err != nil {
-		return fmt.Errorf("failed to create watcher: %v", err)
+	patterns := make([]string, 0)
+	for _, wt := range w.tasks {
+		for name, taskPatterns := range wt.graph.patterns {
+			expanded, err := wt.runner.vars.expandSlice(taskPatterns)
+			if err != nil {
+				return fmt.Errorf("task %q: %v", name, err)
+			}
+			wt.graph.patterns[name] = expanded
+		}
+		patterns = append(patterns, wt.graph.allPatterns()...)
 	}
-	defer watcher.Close()
 
-	if err := w.setupWatchPaths(watcher); err != nil {
+	backend, err := w.newBackend(newWatchScope(patterns, watchIgnores(w.config.Globals)))
+	if err != nil {
 		return err
 	}
+	defer backend.Close()
+
+	var keys <-chan byte
+	if w.interactive() {
+		var restore func()
+		var ok bool
+		if keys, restore, ok = readKeys(); ok {
+			defer restore()
+			// Keystrokes belong to the watcher, so tasks get no stdin.
+			for _, wt := range w.tasks {
+				wt.runner.executor.stdin = nil
+			}
+		}
+	}
 
-	w.log.Info("\nWatching for changes... (Press Ctrl+C to stop)\n")
+	if keys != nil {
+		w.log.Info("\nWatching for changes... (%s)\n", keyHelp)
+	} else {
+		w.log.Info("\nWatching for changes... (Press Ctrl+C to stop)\n")
+	}
 
-	w.runTask()
+	for _, wt := range w.tasks {
+		if wt.Options.RunOnStart {
+			w.startTask(wt, true)
+		}
+	}
 
-	return w.eventLoop(watcher)
+	return w.eventLoop(backend, keys)
 }
 
-func (w *Watcher) setupWatchPaths(watcher *fsnotify.Watcher) error {
-	dirs := make(map[string]bool)
-
-	for _, pattern := range w.patterns {
-		matches, err := expandGlobPattern(pattern)
-		if err != nil {
-			w.log.Warning("invalid pattern %q: %v", pattern, err)
-			continue
-		}
-
-		w.log.Debug("Pattern %q matched %d files", pattern, len(matches))
-		for _, match := range matches {
-			w.log.Debug("  - %s", match)
+// interactive reports whether keyboard controls may be used. Tasks that read
+// stdin themselves opt out with "interactive false" in their watch block.
+func (w *Watcher) interactive() bool {
+	for _, wt := range w.tasks {
+		if !wt.Options.Interactive {
+			return false
 		}
+	}
+	return true
+}
 
-		for _, match := range matches {
-			dir := filepath.Dir(match)
-			if !dirs[dir] {
-				dirs[dir] = true
-				if err := watcher.Add(dir); err != nil {
-					w.log.Warning("failed to watch directory %q: %v", dir, err)
-				} else {
-					w.log.Info("Watching directory: %s", dir)
-				}
-			}
+func (w *Watcher) newBackend(scope *watchScope) (watchBackend, error) {
+	for _, root := range scope.roots {
+		if root.recursive {
+			w.log.Info("Watching directory: %s (recursive)", root.dir)
+		} else {
+			w.log.Info("Watching directory: %s", root.dir)
 		}
 	}
 
-	if len(dirs) == 0 {
-		return fmt.Errorf("no valid paths to watch")
+	if w.PollInterval > 0 {
+		w.log.Info("Polling for changes every %s", w.PollInterval)
+		return newPollBackend(scope, w.PollInterval), nil
 	}
-
-	return nil
+	return newFSNotifyBackend(scope, w.log)
 }
 
-func (w *Watcher) eventLoop(watcher *fsnotify.Watcher) error {
+func (w *Watcher) eventLoop(backend watchBackend, keys <-chan byte) error {
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 	defer signal.Stop(sigChan)
 
-	debounce := time.NewTimer(0)
-	if !debounce.Stop() {
-		<-debounce.C
-	}
-
-	taskDone := make(chan struct{}, 1)
-
 	for {
 		select {
 		case <-sigChan:
-			w.log.Info("\nShutting down watcher...")
-			w.cancelCurrentTask()
+			w.shutdown()
 			return nil
 
-		case event, ok := <-watcher.Events:
+		case key, ok := <-keys:
 			if !ok {
+				keys = nil
+				continue
+			}
+			if quit := w.handleKey(key); quit {
+				w.shutdown()
 				return nil
 			}
-			w.handleEvent(event, debounce)
 
-		case <-debounce.C:
-			w.cancelCurrentTask()
-			w.log.Info("\nRerunning task...")
-			go w.runTaskAsync(taskDone)
+		case event := <-backend.Events():
+			if w.paused {
+				continue
+			}
+			w.handleEvent(event)
 
-		case <-taskDone:
+		case wt := <-w.pending:
+			w.rerunTask(wt)
 
-		case err, ok := <-watcher.Errors:
-			if !ok {
-				return nil
-			}
+		case err := <-backend.Errors():
 			w.log.Error("Watcher error: %v", err)
 		}
 	}
 }
 
-func (w *Watcher) handleEvent(event fsnotify.Event, debounce *time.Timer) {
-	if !w.isRelevantEvent(event) {
-		return
+func (w *Watcher) shutdown() {
+	w.log.Info("\nShutting down watcher...")
+	close(w.done)
+	for _, wt := range w.tasks {
+		wt.mu.Lock()
+		wt.generation++
+		if wt.debounce != nil {
+			wt.debounce.Stop()
+		}
+		wt.mu.Unlock()
+		w.cancelTask(wt)
 	}
-
-	if !w.matchesPattern(event.Name) {
-		return
+	for _, wt := range w.tasks {
+		wt.runMu.Lock()
+		wt.runMu.Unlock()
 	}
-
-	w.log.Info("\nFile changed: %s (%s)", event.Name, event.Op.String())
-	w.resetDebounce(debounce)
+	w.stopServices()
 }
 
-func (w *Watcher) isRelevantEvent(event fsnotify.Event) bool {
-	return event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Remove|fsnotify.Rename) != 0
+// handleKey runs the action bound to a keystroke and reports whether the
+// watcher should quit.
+func (w *Watcher) handleKey(key byte) bool {
+	switch key {
+	case 'r', 'R', '\r', '\n':
+		w.log.Info("\nRerunning...")
+		for _, wt := range w.tasks {
+			w.cancelTask(wt)
+		}
+		w.stopServices()
+		for _, wt := range w.tasks {
+			w.startTask(wt, true)
+		}
+	case 'f', 'F':
+		w.log.Info("\nForce rerunning, ignoring the cache...")
+		for _, wt := range w.tasks {
+			wt.mu.Lock()
+			wt.force = true
+			wt.mu.Unlock()
+			w.cancelTask(wt)
+		}
+		w.stopServices()
+		for _, wt := range w.tasks {
+			w.startTask(wt, true)
+		}
+	case 'c', 'C':
+		fmt.Print(clearScreen)
+	case 'p', 'P':
+		w.paused = !w.paused
+		if w.paused {
+			w.log.Info("Watching paused, press p to resume")
+		} else {
+			w.log.Info("Watching resumed")
+		}
+	case 'q', 'Q', 3:
+		return true
+	}
+	return false
 }
 
-func (w *Watcher) matchesPattern(filePath string) bool {
-	for _, pattern := range w.patterns {
-		if matchesGlobPattern(pattern, filePath) {
-			return true
+func (w *Watcher) handleEvent(event fileEvent) {
+	logged := false
+	for _, wt := range w.tasks {
+		matched := wt.graph.matching(event.Path)
+		if len(matched) == 0 {
+			continue
+		}
+		if !logged {
+			w.log.Info("\nFile changed: %s (%s)", event.Path, event.Op)
+			logged = true
 		}
+		wt.mu.Lock()
+		for _, name := range matched {
+			wt.changed[name] = true
+		}
+		wt.mu.Unlock()
+		w.resetDebounce(wt)
 	}
-	return false
 }
 
-func (w *Watcher) resetDebounce(debounce *time.Timer) {
-	if !debounce.Stop() {
+func (w *Watcher) resetDebounce(wt *watchedTask) {
+	wt.mu.Lock()
+	defer wt.mu.Unlock()
+
+	if wt.debounce != nil {
+		wt.debounce.Stop()
+	}
+	delay, err := time.ParseDuration(wt.Options.Debounce)
+	if err != nil {
+		delay = 500 * time.Millisecond
+	}
+	wt.debounce = time.AfterFunc(delay, func() {
 		select {
-		case <-debounce.C:
-		default:
+		case w.pending <- wt:
+		case <-w.done:
 		}
-	}
-	debounce.Reset(500 * time.Millisecond)
+	})
 }
 
-func (w *Watcher) runTask() {
-	if err := w.runner.RunTask(w.task, w.extraArgs...); err != nil {
-		w.log.Error("%v", err)
+// rerunTask re-runs the task after a change according to its watch mode: a
+// running task is restarted, finishes before the next run, or keeps running
+// while the change is ignored.
+func (w *Watcher) rerunTask(wt *watchedTask) {
+	if wt.isRunning() {
+		switch wt.Options.Mode {
+		case models.WatchModeIgnoreWhileRunning:
+			w.log.Info("Task %q is still running, ignoring change", wt.Task.Name)
+			wt.mu.Lock()
+			wt.changed = make(map[string]bool)
+			wt.mu.Unlock()
+			return
+		case models.WatchModeQueue:
+			w.log.Info("Task %q is still running, queued another run", wt.Task.Name)
+			w.startTask(wt, false)
+			return
+		}
 	}
+
+	w.cancelTask(wt)
+	w.log.Info("\nRerunning task %q...", wt.Task.Name)
+	w.startTask(wt, false)
+}
+
+func (wt *watchedTask) isRunning() bool {
+	wt.mu.Lock()
+	defer wt.mu.Unlock()
+	return wt.cancelFunc != nil
+}
+
+// startTask runs the task in the background. A full run executes the whole
+// dependency graph, restarting the services stopped beforehand; otherwise
+// only the tasks affected by the changes seen since the last run and their
+// dependents are executed again.
+func (w *Watcher) startTask(wt *watchedTask, full bool) {
+	wt.mu.Lock()
+	wt.generation++
+	generation := wt.generation
+	wt.mu.Unlock()
+
+	go w.runTaskAsync(wt, generation, full)
 }
 
-func (w *Watcher) runTaskAsync(done chan<- struct{}) {
-	w.taskMu.Lock()
+// runTaskAsync runs the task once, waiting for a cancelled previous run of
+// the same task to exit first. Runs superseded while waiting are skipped.
+func (w *Watcher) runTaskAsync(wt *watchedTask, generation int, full bool) {
+	wt.runMu.Lock()
+	defer wt.runMu.Unlock()
+
 	ctx, cancel := context.WithCancel(context.Background())
-	w.cancelFunc = cancel
-	w.taskMu.Unlock()
+	wt.mu.Lock()
+	if wt.generation != generation {
+		wt.mu.Unlock()
+		cancel()
+		return
+	}
+	wt.cancelFunc = cancel
+	force := wt.force
+	wt.force = false
+	changed := wt.changed
+	wt.changed = make(map[string]bool)
+	wt.mu.Unlock()
 
 	defer func() {
-		w.taskMu.Lock()
-		w.cancelFunc = nil
-		w.taskMu.Unlock()
-		select {
-		case done <- struct{}{}:
-		default:
-		}
+		wt.mu.Lock()
+		wt.cancelFunc = nil
+		wt.mu.Unlock()
+		cancel()
 	}()
 
-	w.runner.Reset()
-	w.log.Debug("Starting task in goroutine...")
+	if wt.Options.Clear {
+		fmt.Print(clearScreen)
+	}
+
+	if full {
+		wt.runner.Reset()
+	} else {
+		affected := wt.graph.affected(changed)
+		affected[wt.Task.Name] = true
+		wt.runner.invalidate(affected)
+		w.log.Info("Affected tasks: %s", strings.Join(sortedNames(affected), ", "))
+	}
+	wt.runner.Force = force
+	w.log.Debug("Starting task %q in goroutine...", wt.Task.Name)
 
-	err := w.runner.RunTaskWithContext(ctx, w.task, w.extraArgs...)
-	w.log.Debug("Task goroutine finished with err: %v", err)
+	err := wt.runner.RunTaskWithContext(ctx, wt.Task, wt.ExtraArgs...)
+	w.log.Debug("Task %q goroutine finished with err: %v", wt.Task.Name, err)
 
 	if ctx.Err() == context.Canceled {
-		w.log.Warning("Task cancelled")
+		w.log.Warning("Task %q cancelled", wt.Task.Name)
 		return
 	}
 
@@ -203,10 +390,10 @@ func (w *Watcher) runTaskAsync(done chan<- struct{}) {
 	}
 }
 
-func (w *Watcher) cancelCurrentTask() {
-	w.taskMu.Lock()
-	defer w.taskMu.Unlock()
-	if w.cancelFunc != nil {
-		w.cancelFunc()
+func (w *Watcher) cancelTask(wt *watchedTask) {
+	wt.mu.Lock()
+	defer wt.mu.Unlock()
+	if wt.cancelFunc != nil {
+		wt.cancelFunc()
 	}
 }
@@ -0,0 +1,55 @@
+package runner
+
+import (
+	"fmt"
+
+	"github.com/azuyamat/pace/internal/config"
+	"github.com/azuyamat/pace/internal/models"
+)
+
+// WorkspaceTasks returns the task called name in the workspace root and in
+// every member that defines it, ordered so that each task comes after the
+// tasks it depends on.
+func WorkspaceTasks(cfg *config.Config, name string) ([]models.Task, error) {
+	if cfg.Workspace == nil {
+		return nil, fmt.Errorf("--all needs a workspace, declare one with: workspace { members [\"services/*\"] }")
+	}
+
+	targets := make(map[string]bool)
+	candidates := append([]string{name}, cfg.Workspace.Members...)
+	for i := 1; i < len(candidates); i++ {
+		candidates[i] += ":" + name
+	}
+	for _, candidate := range candidates {
+		if _, exists := cfg.Tasks[candidate]; exists {
+			targets[candidate] = true
+		}
+	}
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("no task '%s' in the workspace root or its members", name)
+	}
+
+	tasks := make([]models.Task, 0, len(targets))
+	visited := make(map[string]bool)
+	var visit func(name string)
+	visit = func(name string) {
+		if visited[name] {
+			return
+		}
+		visited[name] = true
+		task, exists := cfg.Tasks[name]
+		if !exists {
+			return
+		}
+		for _, dep := range task.DependsOn {
+			visit(dep)
+		}
+		if targets[name] {
+			tasks = append(tasks, task)
+		}
+	}
+	for _, candidate := range candidates {
+		visit(candidate)
+	}
+	return tasks, nil
+}